@@ -0,0 +1,249 @@
+package vast
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parameters of a TagContext, see AdTagURL.Names
+const (
+	TAG_WIDTH        = "width"
+	TAG_HEIGHT       = "height"
+	TAG_CONTENT_URL  = "content_url"
+	TAG_PAGE_URL     = "page_url"
+	TAG_APP_BUNDLE   = "app_bundle"
+	TAG_IFA          = "ifa"
+	TAG_IFA_TYPE     = "ifa_type"
+	TAG_LMT          = "lmt"
+	TAG_GDPR         = "gdpr"
+	TAG_GDPR_CONSENT = "gdpr_consent"
+	TAG_US_PRIVACY   = "us_privacy"
+	TAG_COPPA        = "coppa"
+	TAG_CACHE_BUSTER = "cache_buster"
+)
+
+// tagParams lists the parameters in the order they are appended to a tag
+var tagParams = []string{
+	TAG_WIDTH, TAG_HEIGHT, TAG_CONTENT_URL, TAG_PAGE_URL, TAG_APP_BUNDLE,
+	TAG_IFA, TAG_IFA_TYPE, TAG_LMT, TAG_GDPR, TAG_GDPR_CONSENT, TAG_US_PRIVACY,
+	TAG_COPPA, TAG_CACHE_BUSTER,
+}
+
+// DefaultTagParams are the query parameter names of the TAG_ parameters used
+// unless AdTagURL.Names overrides them.
+var DefaultTagParams = map[string]string{
+	TAG_WIDTH:        "w",
+	TAG_HEIGHT:       "h",
+	TAG_CONTENT_URL:  "content_url",
+	TAG_PAGE_URL:     "page_url",
+	TAG_APP_BUNDLE:   "app_bundle",
+	TAG_IFA:          "ifa",
+	TAG_IFA_TYPE:     "ifa_type",
+	TAG_LMT:          "lmt",
+	TAG_GDPR:         "gdpr",
+	TAG_GDPR_CONSENT: "gdpr_consent",
+	TAG_US_PRIVACY:   "us_privacy",
+	TAG_COPPA:        "coppa",
+	TAG_CACHE_BUSTER: "cb",
+}
+
+// TagContext is the request context sent to an ad server in a tag URL.
+type TagContext struct {
+	// Player size
+	Width, Height int
+	ContentURL    string
+	PageURL       string
+	AppBundle     string
+	// Device advertising identifier and its type, e.g. idfa, aaid or rida
+	IFA     string
+	IFAType string
+	// Privacy signals, see Consent. Whether GDPR applies is unknown, and not
+	// sent, if GDPR is nil.
+	GDPR            *bool
+	ConsentString   string
+	USPrivacy       string
+	LimitAdTracking bool
+	COPPA           bool
+	// Cache-buster value, the [CACHEBUSTING] macro if empty
+	CacheBuster string
+}
+
+// Consent returns the privacy signals of the context. GDPR applies only if it
+// is known to.
+func (ctx TagContext) Consent() Consent {
+	return Consent{
+		GDPR:            ctx.GDPR != nil && *ctx.GDPR,
+		ConsentString:   ctx.ConsentString,
+		USPrivacy:       ctx.USPrivacy,
+		LimitAdTracking: ctx.LimitAdTracking,
+		COPPA:           ctx.COPPA,
+	}
+}
+
+// AdTagURL builds tag URLs carrying a TagContext, and parses them back.
+type AdTagURL struct {
+	// Base tag URL, its own query parameters are kept
+	Base string
+	// Query parameter names by TAG_ parameter, for the partners which do not
+	// use DefaultTagParams. An empty name leaves the parameter out.
+	Names map[string]string
+}
+
+func (t AdTagURL) name(param string) string {
+	if name, ok := t.Names[param]; ok {
+		return name
+	}
+	return DefaultTagParams[param]
+}
+
+// macros left unescaped so the player can expand them
+var tagMacro = regexp.MustCompile(`^\[[A-Z_]+\]$`)
+
+// Build returns the base URL with the context appended. Empty values, false
+// flags and an unknown gdpr are left out, a known gdpr is sent as 0 or 1.
+// Parameters of the base URL with the same name are replaced. Values are query escaped except macros such as [CACHEBUSTING].
+func (t AdTagURL) Build(ctx TagContext) (string, error) {
+	u, err := parseTagURI(t.Base)
+	if err != nil {
+		return "", err
+	}
+
+	values := ctx.values()
+	var params []string
+	names := map[string]bool{}
+	for _, param := range tagParams {
+		name, value := t.name(param), values[param]
+		if name == "" || value == "" {
+			continue
+		}
+		names[name] = true
+		if !tagMacro.MatchString(value) {
+			value = url.QueryEscape(value)
+		}
+		params = append(params, url.QueryEscape(name)+"="+value)
+	}
+
+	// keep the parameters of the base URL, in order
+	var query []string
+	for _, kv := range strings.Split(u.RawQuery, "&") {
+		if kv == "" {
+			continue
+		}
+		name, err := url.QueryUnescape(strings.SplitN(kv, "=", 2)[0])
+		if err != nil || !names[name] {
+			query = append(query, kv)
+		}
+	}
+	u.RawQuery = strings.Join(append(query, params...), "&")
+	return u.String(), nil
+}
+
+func (ctx TagContext) values() map[string]string {
+	flag := func(b bool) string {
+		if b {
+			return "1"
+		}
+		return ""
+	}
+	size := func(n int) string {
+		if n <= 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	cb := ctx.CacheBuster
+	if cb == "" {
+		cb = "[CACHEBUSTING]"
+	}
+	var gdpr string
+	if ctx.GDPR != nil {
+		gdpr = "0"
+		if *ctx.GDPR {
+			gdpr = "1"
+		}
+	}
+	return map[string]string{
+		TAG_WIDTH:        size(ctx.Width),
+		TAG_HEIGHT:       size(ctx.Height),
+		TAG_CONTENT_URL:  ctx.ContentURL,
+		TAG_PAGE_URL:     ctx.PageURL,
+		TAG_APP_BUNDLE:   ctx.AppBundle,
+		TAG_IFA:          ctx.IFA,
+		TAG_IFA_TYPE:     ctx.IFAType,
+		TAG_LMT:          flag(ctx.LimitAdTracking),
+		TAG_GDPR:         gdpr,
+		TAG_GDPR_CONSENT: ctx.ConsentString,
+		TAG_US_PRIVACY:   ctx.USPrivacy,
+		TAG_COPPA:        flag(ctx.COPPA),
+		TAG_CACHE_BUSTER: cb,
+	}
+}
+
+// Parse returns the context held by the query parameters of tag.
+func (t AdTagURL) Parse(tag string) (TagContext, error) {
+	var ctx TagContext
+	u, err := url.Parse(tag)
+	if err != nil {
+		return ctx, fmt.Errorf("bad tag uri %s", err)
+	}
+	q, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return ctx, fmt.Errorf("bad tag uri %s", err)
+	}
+
+	for _, param := range tagParams {
+		name := t.name(param)
+		if name == "" {
+			continue
+		}
+		value := q.Get(name)
+		if value == "" {
+			continue
+		}
+		switch param {
+		case TAG_WIDTH, TAG_HEIGHT:
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return ctx, fmt.Errorf("bad %s %q", name, value)
+			}
+			if param == TAG_WIDTH {
+				ctx.Width = n
+			} else {
+				ctx.Height = n
+			}
+		case TAG_LMT, TAG_GDPR, TAG_COPPA:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return ctx, fmt.Errorf("bad %s %q", name, value)
+			}
+			switch param {
+			case TAG_LMT:
+				ctx.LimitAdTracking = b
+			case TAG_GDPR:
+				ctx.GDPR = &b
+			default:
+				ctx.COPPA = b
+			}
+		case TAG_CONTENT_URL:
+			ctx.ContentURL = value
+		case TAG_PAGE_URL:
+			ctx.PageURL = value
+		case TAG_APP_BUNDLE:
+			ctx.AppBundle = value
+		case TAG_IFA:
+			ctx.IFA = value
+		case TAG_IFA_TYPE:
+			ctx.IFAType = value
+		case TAG_GDPR_CONSENT:
+			ctx.ConsentString = value
+		case TAG_US_PRIVACY:
+			ctx.USPrivacy = value
+		case TAG_CACHE_BUSTER:
+			ctx.CacheBuster = value
+		}
+	}
+	return ctx, nil
+}
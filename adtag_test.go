@@ -0,0 +1,88 @@
+package vast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdTagURLBuild(t *testing.T) {
+	tag := AdTagURL{Base: "https://ads.example.com/vast?zone=12&cb=old"}
+	applies := true
+	ctx := TagContext{
+		Width:         640,
+		Height:        360,
+		ContentURL:    "https://video.example.com/watch?v=1&t=2",
+		IFA:           "6d92078a-8246-4ba4-ae5b-76104861e7dc",
+		IFAType:       "rida",
+		GDPR:          &applies,
+		ConsentString: "CO+/x=",
+		USPrivacy:     "1YNN",
+	}
+
+	uri, err := tag.Build(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ads.example.com/vast?zone=12&w=640&h=360"+
+		"&content_url=https%3A%2F%2Fvideo.example.com%2Fwatch%3Fv%3D1%26t%3D2"+
+		"&ifa=6d92078a-8246-4ba4-ae5b-76104861e7dc&ifa_type=rida"+
+		"&gdpr=1&gdpr_consent=CO%2B%2Fx%3D&us_privacy=1YNN&cb=[CACHEBUSTING]", uri)
+
+	parsed, err := tag.Parse(uri)
+	assert.NoError(t, err)
+	ctx.CacheBuster = "[CACHEBUSTING]"
+	assert.Equal(t, ctx, parsed)
+
+	_, err = AdTagURL{Base: "/vast"}.Build(ctx)
+	assert.EqualError(t, err, "bad tag uri /vast")
+	_, err = AdTagURL{}.Build(ctx)
+	assert.EqualError(t, err, "empty tag uri")
+
+	// gdpr=0 is sent when GDPR is known not to apply, nothing when unknown
+	applies = false
+	uri, err = AdTagURL{Base: "https://ads.example.com/vast?gdpr=1"}.Build(TagContext{GDPR: &applies, CacheBuster: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ads.example.com/vast?gdpr=0&cb=1", uri)
+	uri, err = AdTagURL{Base: "https://ads.example.com/vast"}.Build(TagContext{CacheBuster: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ads.example.com/vast?cb=1", uri)
+	uri, err = AdTagURL{Base: "https://ads.example.com/vast", Names: map[string]string{TAG_GDPR: ""}}.Build(TagContext{GDPR: &applies, CacheBuster: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ads.example.com/vast?cb=1", uri)
+}
+
+func TestAdTagURLNames(t *testing.T) {
+	tag := AdTagURL{
+		Base:  "https://partner.example.com/tag",
+		Names: map[string]string{TAG_WIDTH: "sz_w", TAG_HEIGHT: "sz_h", TAG_CACHE_BUSTER: "", TAG_LMT: "dnt"},
+	}
+	ctx := TagContext{Width: 1920, Height: 1080, AppBundle: "com.example.tv", CacheBuster: "123", LimitAdTracking: true, COPPA: true}
+
+	uri, err := tag.Build(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://partner.example.com/tag?sz_w=1920&sz_h=1080&app_bundle=com.example.tv&dnt=1&coppa=1", uri)
+
+	parsed, err := tag.Parse(uri)
+	assert.NoError(t, err)
+	ctx.CacheBuster = ""
+	assert.Equal(t, ctx, parsed)
+}
+
+func TestAdTagURLParse(t *testing.T) {
+	ctx, err := AdTagURL{}.Parse("https://ads.example.com/vast?w=300&gdpr=0&lmt=true&other=x")
+	assert.NoError(t, err)
+	applies := false
+	assert.Equal(t, TagContext{Width: 300, GDPR: &applies, LimitAdTracking: true}, ctx)
+	assert.Equal(t, Consent{LimitAdTracking: true}, ctx.Consent())
+
+	ctx, err = AdTagURL{}.Parse("https://ads.example.com/vast?gdpr=1&gdpr_consent=CO")
+	assert.NoError(t, err)
+	assert.Equal(t, Consent{GDPR: true, ConsentString: "CO"}, ctx.Consent())
+	ctx, err = AdTagURL{}.Parse("https://ads.example.com/vast")
+	assert.NoError(t, err)
+	assert.Nil(t, ctx.GDPR)
+
+	_, err = AdTagURL{}.Parse("https://ads.example.com/vast?w=large")
+	assert.EqualError(t, err, `bad w "large"`)
+	_, err = AdTagURL{}.Parse("https://ads.example.com/vast?coppa=maybe")
+	assert.EqualError(t, err, `bad coppa "maybe"`)
+}
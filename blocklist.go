@@ -0,0 +1,243 @@
+package vast
+
+import "strings"
+
+// Reasons of a Removal
+const (
+	REMOVE_ADVERTISER      = "blocked advertiser"
+	REMOVE_DOMAIN          = "blocked domain"
+	REMOVE_CATEGORY        = "blocked category"
+	REMOVE_SAME_ADVERTISER = "competing advertiser"
+	REMOVE_SAME_CATEGORY   = "competing category"
+)
+
+// AdFilter lists the advertisers, domains and categories blocked by
+// FilterAds, and the competitive separation rules of the pod.
+type AdFilter struct {
+	// Advertiser names, compared case insensitively
+	Advertisers []string
+	// Advertiser domains, see Ad.Domains. A domain also blocks its subdomains.
+	Domains []string
+	// Category codes, see InLine.Categories. A code also blocks its
+	// subcategories, e.g. IAB7 blocks IAB7-39.
+	Categories []string
+	// Keep at most one ad per advertiser, or per category, in the pod
+	SeparateAdvertisers bool
+	SeparateCategories  bool
+}
+
+// Removal reports an ad removed by FilterAds.
+type Removal struct {
+	// Index and id of the ad before filtering
+	Index int
+	ID    string
+	// One of the REMOVE_ reasons
+	Reason string
+	// The advertiser, domain or category which caused the removal
+	Value string
+}
+
+// FilterAds removes the ads matching the blocklists of f, then the pod ads
+// sharing an advertiser or category with an earlier pod ad if separation is
+// enabled. The remaining pod ads are renumbered from 1 so the pod stays
+// contiguous. It returns why each ad was removed.
+func (v *VAST) FilterAds(f AdFilter) []Removal {
+	var removals []Removal
+	remove := func(i int, reason, value string) {
+		removals = append(removals, Removal{Index: i, ID: v.Ads[i].ID, Reason: reason, Value: value})
+	}
+
+	removed := make([]bool, len(v.Ads))
+	for i := range v.Ads {
+		ad := &v.Ads[i]
+		if name := ad.advertiser(); name != "" && containsFold(f.Advertisers, name) {
+			remove(i, REMOVE_ADVERTISER, name)
+		} else if domain := matchDomains(ad.Domains(), f.Domains); domain != "" {
+			remove(i, REMOVE_DOMAIN, domain)
+		} else if code := matchCategories(ad.categories(), f.Categories); code != "" {
+			remove(i, REMOVE_CATEGORY, code)
+		} else {
+			continue
+		}
+		removed[i] = true
+	}
+
+	// competitive separation, in playback order
+	if f.SeparateAdvertisers || f.SeparateCategories {
+		advertisers := map[string]bool{}
+		categories := map[string]bool{}
+		for _, ad := range v.Pod() {
+			i := v.adIndex(ad)
+			if removed[i] {
+				continue
+			}
+			name := strings.ToLower(ad.advertiser())
+			if f.SeparateAdvertisers && name != "" && advertisers[name] {
+				remove(i, REMOVE_SAME_ADVERTISER, ad.advertiser())
+				removed[i] = true
+				continue
+			}
+			if f.SeparateCategories {
+				if code := matchCategory(ad.categories(), categories); code != "" {
+					remove(i, REMOVE_SAME_CATEGORY, code)
+					removed[i] = true
+					continue
+				}
+			}
+			advertisers[name] = true
+			for _, code := range ad.categories() {
+				categories[strings.ToUpper(code)] = true
+			}
+		}
+	}
+
+	if len(removals) == 0 {
+		return nil
+	}
+	ads := v.Ads[:0]
+	for i, ad := range v.Ads {
+		if !removed[i] {
+			ads = append(ads, ad)
+		}
+	}
+	v.Ads = ads
+	v.renumberPod()
+	return removals
+}
+
+// Domains returns the advertiser domains of the ad: the hosts of its click
+// through URLs, lower case and without a www. prefix.
+func (ad *Ad) Domains() []string {
+	var domains []string
+	add := func(uri string) {
+		host := strings.TrimPrefix(uriHost(uri), "www.")
+		if host == "" {
+			return
+		}
+		for _, d := range domains {
+			if d == host {
+				return
+			}
+		}
+		domains = append(domains, host)
+	}
+	clicks := func(c *VideoClicks) {
+		if c != nil {
+			for _, click := range c.ClickThroughs {
+				add(click.URI)
+			}
+		}
+	}
+
+	if ad.InLine != nil {
+		for _, c := range ad.InLine.Creatives {
+			if c.Linear != nil {
+				clicks(c.Linear.VideoClicks)
+			}
+			if c.NonLinearAds != nil {
+				for _, nl := range c.NonLinearAds.NonLinears {
+					add(nl.NonLinearClickThrough.CDATA)
+				}
+			}
+			if c.CompanionAds != nil {
+				for _, comp := range c.CompanionAds.Companions {
+					add(comp.CompanionClickThrough.CDATA)
+				}
+			}
+		}
+	} else if ad.Wrapper != nil {
+		for _, c := range ad.Wrapper.Creatives {
+			if c.Linear != nil {
+				clicks(c.Linear.VideoClicks)
+			}
+			if c.CompanionAds != nil {
+				for _, comp := range c.CompanionAds.Companions {
+					add(comp.CompanionClickThrough.CDATA)
+				}
+			}
+		}
+	}
+	return domains
+}
+
+func (ad *Ad) advertiser() string {
+	if ad.InLine == nil {
+		return ""
+	}
+	return strings.TrimSpace(ad.InLine.Advertiser)
+}
+
+func (ad *Ad) categories() []string {
+	if ad.InLine == nil {
+		return nil
+	}
+	var codes []string
+	for _, c := range ad.InLine.Categories {
+		if code := strings.TrimSpace(c.Code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// adIndex returns the index in v.Ads of an ad returned by Pod or Buffet
+func (v *VAST) adIndex(ad *Ad) int {
+	for i := range v.Ads {
+		if &v.Ads[i] == ad {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsFold(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(strings.TrimSpace(l), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDomain reports whether host is domain or one of its subdomains
+func matchDomain(host, domain string) bool {
+	domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
+
+// matchDomains returns the first host matching one of the domains
+func matchDomains(hosts, domains []string) string {
+	for _, host := range hosts {
+		for _, domain := range domains {
+			if matchDomain(host, domain) {
+				return host
+			}
+		}
+	}
+	return ""
+}
+
+// matchCategories returns the first code equal to or a subcategory of one of
+// the blocked codes
+func matchCategories(codes, blocked []string) string {
+	for _, code := range codes {
+		c := strings.ToUpper(code)
+		for _, b := range blocked {
+			b = strings.ToUpper(strings.TrimSpace(b))
+			if b != "" && (c == b || strings.HasPrefix(c, b+"-")) {
+				return code
+			}
+		}
+	}
+	return ""
+}
+
+// matchCategory returns the first code already in seen
+func matchCategory(codes []string, seen map[string]bool) string {
+	for _, code := range codes {
+		if seen[strings.ToUpper(code)] {
+			return code
+		}
+	}
+	return ""
+}
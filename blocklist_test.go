@@ -0,0 +1,77 @@
+package vast
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func filterAd(id string, seq int, advertiser, click string, categories ...string) Ad {
+	ad := podAd(id, seq, 15*time.Second)
+	ad.InLine.Advertiser = advertiser
+	ad.InLine.Creatives[0].Linear.VideoClicks = &VideoClicks{ClickThroughs: []VideoClick{{URI: click}}}
+	for _, code := range categories {
+		ad.InLine.Categories = append(ad.InLine.Categories, Category{Code: code})
+	}
+	return ad
+}
+
+func TestCategoryXML(t *testing.T) {
+	var inline InLine
+	err := xml.Unmarshal([]byte(`<InLine><Category authority="https://www.iabtechlab.com/categoriesv2">IAB7-39</Category><Category>IAB2</Category></InLine>`), &inline)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Category{{Authority: "https://www.iabtechlab.com/categoriesv2", Code: "IAB7-39"}, {Code: "IAB2"}}, inline.Categories)
+	}
+}
+
+func TestAdDomains(t *testing.T) {
+	ad := filterAd("a", 0, "", "https://WWW.Shop.com/landing?x=1")
+	ad.InLine.Creatives = append(ad.InLine.Creatives, Creative{CompanionAds: &CompanionAds{Companions: []Companion{
+		{CompanionClickThrough: CDATAString{"http://shop.com/c"}},
+		{CompanionClickThrough: CDATAString{"http://ads.brand.com/c"}},
+	}}})
+	assert.Equal(t, []string{"shop.com", "ads.brand.com"}, ad.Domains())
+
+	// malformed click throughs
+	ad = filterAd("b", 0, "", "[PROTOCOL]://www.rival.com/landing?q=%zz")
+	assert.Equal(t, []string{"rival.com"}, ad.Domains())
+	assert.Equal(t, REMOVE_DOMAIN, (&VAST{Ads: []Ad{ad}}).FilterAds(AdFilter{Domains: []string{"rival.com"}})[0].Reason)
+}
+
+func TestFilterAds(t *testing.T) {
+	v := &VAST{Ads: []Ad{
+		filterAd("cola", 1, "Cola", "http://cola.com", "IAB8"),
+		filterAd("cola2", 2, "COLA", "http://cola.com/2", "IAB8"),
+		filterAd("pharma", 3, "Pills", "http://pills.com", "IAB7-39"),
+		filterAd("casino", 4, "", "http://go.casino.com/x", "IAB9"),
+		filterAd("juice", 5, "Juice", "http://juice.com", "IAB8"),
+		filterAd("rival", 6, "Rival", "http://rival.com", "IAB2"),
+		filterAd("buffet", 0, "Cola", "http://cola.com", "IAB8"),
+	}}
+
+	removals := v.FilterAds(AdFilter{
+		Advertisers:         []string{"rival"},
+		Domains:             []string{"casino.com"},
+		Categories:          []string{"IAB7"},
+		SeparateAdvertisers: true,
+		SeparateCategories:  true,
+	})
+	assert.Equal(t, []Removal{
+		{Index: 2, ID: "pharma", Reason: REMOVE_CATEGORY, Value: "IAB7-39"},
+		{Index: 3, ID: "casino", Reason: REMOVE_DOMAIN, Value: "go.casino.com"},
+		{Index: 5, ID: "rival", Reason: REMOVE_ADVERTISER, Value: "Rival"},
+		{Index: 1, ID: "cola2", Reason: REMOVE_SAME_ADVERTISER, Value: "COLA"},
+		{Index: 4, ID: "juice", Reason: REMOVE_SAME_CATEGORY, Value: "IAB8"},
+	}, removals)
+
+	// the buffet is not subject to separation, the pod is renumbered
+	if assert.Len(t, v.Ads, 2) {
+		assert.Equal(t, "cola", v.Ads[0].ID)
+		assert.Equal(t, "buffet", v.Ads[1].ID)
+	}
+	assert.NoError(t, v.ValidatePod())
+
+	assert.Nil(t, v.FilterAds(AdFilter{}))
+}
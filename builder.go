@@ -0,0 +1,369 @@
+package vast
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// InLineBuilder builds a document holding a single InLine ad, see NewInLine.
+// Methods record the first error encountered, which is returned by Build.
+type InLineBuilder struct {
+	v        *VAST
+	inline   *InLine
+	creative *Creative
+	err      error
+}
+
+// NewInLine starts a VAST 3.0 document with a single InLine ad:
+//
+//	v, err := vast.NewInLine("ad-1").
+//		AdSystem("my-server", "1.0").
+//		AdTitle("My ad").
+//		Impression("https://example.com/imp").
+//		Linear(vast.Duration(15 * time.Second)).
+//		MediaFile("https://example.com/ad.mp4", "video/mp4", 1280, 720).
+//		Tracking(vast.TRACK_START, "https://example.com/start").
+//		Build()
+func NewInLine(id string) *InLineBuilder {
+	b := &InLineBuilder{inline: &InLine{}}
+	b.v = &VAST{Version: "3.0", Ads: []Ad{{ID: id, InLine: b.inline}}}
+	return b
+}
+
+func (b *InLineBuilder) fail(err error) *InLineBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// Version sets the VAST version of the document
+func (b *InLineBuilder) Version(version string) *InLineBuilder {
+	b.v.Version = version
+	return b
+}
+
+// Sequence sets the sequence of the ad in a pod
+func (b *InLineBuilder) Sequence(seq int) *InLineBuilder {
+	b.v.Ads[0].Sequence = seq
+	return b
+}
+
+// AdSystem sets the name and version of the ad server
+func (b *InLineBuilder) AdSystem(name, version string) *InLineBuilder {
+	b.inline.AdSystem = &AdSystem{Name: name, Version: version}
+	return b
+}
+
+// AdTitle sets the title of the ad
+func (b *InLineBuilder) AdTitle(title string) *InLineBuilder {
+	b.inline.AdTitle = CDATAString{title}
+	return b
+}
+
+// Description sets the description of the ad
+func (b *InLineBuilder) Description(desc string) *InLineBuilder {
+	b.inline.Description = CDATAString{desc}
+	return b
+}
+
+// Advertiser sets the advertiser name
+func (b *InLineBuilder) Advertiser(name string) *InLineBuilder {
+	b.inline.Advertiser = name
+	return b
+}
+
+// Pricing sets the price of the ad
+func (b *InLineBuilder) Pricing(price string) *InLineBuilder {
+	b.inline.Pricing = price
+	return b
+}
+
+// Impression adds an impression pixel
+func (b *InLineBuilder) Impression(uri string) *InLineBuilder {
+	b.inline.Impressions = append(b.inline.Impressions, Impression{URI: uri})
+	return b
+}
+
+// Error adds an error pixel
+func (b *InLineBuilder) Error(uri string) *InLineBuilder {
+	b.inline.Errors = append(b.inline.Errors, CDATAString{uri})
+	return b
+}
+
+// Extension adds an extension, given either as an Extension or as a value
+// registered with RegisterExtension.
+func (b *InLineBuilder) Extension(ext interface{}) *InLineBuilder {
+	e, err := toExtension(ext)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.inline.Extensions = append(b.inline.Extensions, e)
+	return b
+}
+
+// Linear adds a linear creative of the given duration. The following creative
+// methods (MediaFile, Tracking, ...) apply to this creative.
+func (b *InLineBuilder) Linear(dur Duration) *InLineBuilder {
+	b.inline.Creatives = append(b.inline.Creatives, Creative{Linear: &Linear{Duration: dur}})
+	b.creative = &b.inline.Creatives[len(b.inline.Creatives)-1]
+	return b
+}
+
+func (b *InLineBuilder) linear(method string) *Linear {
+	if b.creative == nil {
+		b.fail(fmt.Errorf("%s called before Linear", method))
+		return nil
+	}
+	return b.creative.Linear
+}
+
+// CreativeID sets the id and ad id of the current creative
+func (b *InLineBuilder) CreativeID(id, adID string) *InLineBuilder {
+	if b.linear("CreativeID") != nil {
+		b.creative.ID = id
+		b.creative.AdID = adID
+	}
+	return b
+}
+
+// SkipOffset makes the current linear creative skippable after offset
+func (b *InLineBuilder) SkipOffset(offset Offset) *InLineBuilder {
+	if l := b.linear("SkipOffset"); l != nil {
+		l.SkipOffset = &offset
+	}
+	return b
+}
+
+// MediaFile adds a progressive media file to the current linear creative
+func (b *InLineBuilder) MediaFile(uri, mimeType string, width, height int) *InLineBuilder {
+	return b.Media(MediaFile{Delivery: "progressive", Type: mimeType, Width: width, Height: height, URI: uri})
+}
+
+// Media adds a media file to the current linear creative
+func (b *InLineBuilder) Media(media MediaFile) *InLineBuilder {
+	if l := b.linear("MediaFile"); l != nil {
+		l.MediaFiles = append(l.MediaFiles, media)
+	}
+	return b
+}
+
+// AdParameters sets the parameters of the current linear creative
+func (b *InLineBuilder) AdParameters(params string) *InLineBuilder {
+	if l := b.linear("AdParameters"); l != nil {
+		l.AdParameters = &AdParameters{Parameters: params}
+	}
+	return b
+}
+
+// Tracking adds an event tracker to the current linear creative
+func (b *InLineBuilder) Tracking(event, uri string) *InLineBuilder {
+	if l := b.linear("Tracking"); l != nil {
+		l.TrackingEvents = append(l.TrackingEvents, Tracking{Event: event, URI: uri})
+	}
+	return b
+}
+
+// ClickThrough sets the landing page of the current linear creative
+func (b *InLineBuilder) ClickThrough(uri string) *InLineBuilder {
+	if l := b.linear("ClickThrough"); l != nil {
+		if l.VideoClicks == nil {
+			l.VideoClicks = &VideoClicks{}
+		}
+		l.VideoClicks.ClickThroughs = []VideoClick{{URI: uri}}
+	}
+	return b
+}
+
+// ClickTracking adds a click tracker to the current linear creative
+func (b *InLineBuilder) ClickTracking(uri string) *InLineBuilder {
+	if l := b.linear("ClickTracking"); l != nil {
+		if l.VideoClicks == nil {
+			l.VideoClicks = &VideoClicks{}
+		}
+		l.VideoClicks.ClickTrackings = append(l.VideoClicks.ClickTrackings, VideoClick{URI: uri})
+	}
+	return b
+}
+
+// Build validates and returns the document. The builder must not be used
+// afterwards.
+func (b *InLineBuilder) Build() (*VAST, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.inline.AdSystem == nil || b.inline.AdSystem.Name == "" {
+		return nil, errors.New("empty ad system")
+	}
+	if b.inline.AdTitle.CDATA == "" {
+		return nil, errors.New("empty ad title")
+	}
+	if len(b.inline.Impressions) == 0 {
+		return nil, errors.New("empty impression")
+	}
+	if err := b.v.Validate(); err != nil {
+		return nil, err
+	}
+	return b.v, nil
+}
+
+// WrapperBuilder builds a document holding a single Wrapper ad, see NewWrapper.
+type WrapperBuilder struct {
+	v        *VAST
+	wrapper  *Wrapper
+	creative *CreativeWrapper
+	err      error
+}
+
+// NewWrapper starts a VAST 3.0 document with a single Wrapper ad pointing at
+// tagURI.
+func NewWrapper(tagURI string) *WrapperBuilder {
+	b := &WrapperBuilder{wrapper: &Wrapper{VASTAdTagURI: CDATAString{tagURI}}}
+	b.v = &VAST{Version: "3.0", Ads: []Ad{{Wrapper: b.wrapper}}}
+	return b
+}
+
+func (b *WrapperBuilder) fail(err error) *WrapperBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// ID sets the id of the ad
+func (b *WrapperBuilder) ID(id string) *WrapperBuilder {
+	b.v.Ads[0].ID = id
+	return b
+}
+
+// Version sets the VAST version of the document
+func (b *WrapperBuilder) Version(version string) *WrapperBuilder {
+	b.v.Version = version
+	return b
+}
+
+// Sequence sets the sequence of the ad in a pod
+func (b *WrapperBuilder) Sequence(seq int) *WrapperBuilder {
+	b.v.Ads[0].Sequence = seq
+	return b
+}
+
+// AdSystem sets the name and version of the ad server
+func (b *WrapperBuilder) AdSystem(name, version string) *WrapperBuilder {
+	b.wrapper.AdSystem = &AdSystem{Name: name, Version: version}
+	return b
+}
+
+// Impression adds an impression pixel
+func (b *WrapperBuilder) Impression(uri string) *WrapperBuilder {
+	b.wrapper.Impressions = append(b.wrapper.Impressions, Impression{URI: uri})
+	return b
+}
+
+// Error adds an error pixel
+func (b *WrapperBuilder) Error(uri string) *WrapperBuilder {
+	b.wrapper.Errors = append(b.wrapper.Errors, CDATAString{uri})
+	return b
+}
+
+// Extension adds an extension, given either as an Extension or as a value
+// registered with RegisterExtension.
+func (b *WrapperBuilder) Extension(ext interface{}) *WrapperBuilder {
+	e, err := toExtension(ext)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.wrapper.Extensions = append(b.wrapper.Extensions, e)
+	return b
+}
+
+// FallbackOnNoAd sets whether the player should fall back to another ad when
+// the wrapped tag returns none
+func (b *WrapperBuilder) FallbackOnNoAd(fallback bool) *WrapperBuilder {
+	b.wrapper.FallbackOnNoAd = &fallback
+	return b
+}
+
+func (b *WrapperBuilder) linear() *LinearWrapper {
+	if b.creative == nil {
+		b.wrapper.Creatives = append(b.wrapper.Creatives, CreativeWrapper{Linear: &LinearWrapper{}})
+		b.creative = &b.wrapper.Creatives[0]
+	}
+	return b.creative.Linear
+}
+
+// Tracking adds an event tracker to the wrapped linear creative
+func (b *WrapperBuilder) Tracking(event, uri string) *WrapperBuilder {
+	l := b.linear()
+	l.TrackingEvents = append(l.TrackingEvents, Tracking{Event: event, URI: uri})
+	return b
+}
+
+// ClickTracking adds a click tracker to the wrapped linear creative
+func (b *WrapperBuilder) ClickTracking(uri string) *WrapperBuilder {
+	l := b.linear()
+	if l.VideoClicks == nil {
+		l.VideoClicks = &VideoClicks{}
+	}
+	l.VideoClicks.ClickTrackings = append(l.VideoClicks.ClickTrackings, VideoClick{URI: uri})
+	return b
+}
+
+// Build validates and returns the document. The builder must not be used
+// afterwards.
+func (b *WrapperBuilder) Build() (*VAST, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.wrapper.AdSystem == nil || b.wrapper.AdSystem.Name == "" {
+		return nil, errors.New("empty ad system")
+	}
+	if err := validateTagURI(b.wrapper.VASTAdTagURI.CDATA); err != nil {
+		return nil, err
+	}
+	for i, c := range b.wrapper.Creatives {
+		for j, t := range c.Linear.TrackingEvents {
+			if err := t.Validate(); err != nil {
+				return nil, fmt.Errorf("bad creative[%d] bad track[%d] %s", i, j, err)
+			}
+		}
+	}
+	if err := b.v.Validate(); err != nil {
+		return nil, err
+	}
+	return b.v, nil
+}
+
+// validateTagURI checks a wrapped tag URI is an absolute URL
+func validateTagURI(uri string) error {
+	_, err := parseTagURI(uri)
+	return err
+}
+
+// parseTagURI parses a wrapped tag URI, which must be an absolute URL
+func parseTagURI(uri string) (*url.URL, error) {
+	if uri == "" {
+		return nil, errors.New("empty tag uri")
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("bad tag uri %s", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("bad tag uri %s", uri)
+	}
+	return u, nil
+}
+
+// toExtension returns ext as is if it is an Extension, or encodes it with
+// NewExtension.
+func toExtension(ext interface{}) (Extension, error) {
+	switch e := ext.(type) {
+	case Extension:
+		return e, nil
+	case *Extension:
+		return *e, nil
+	}
+	return NewExtension(ext)
+}
@@ -0,0 +1,100 @@
+package vast
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInLineBuilder(t *testing.T) {
+	d := Duration(5 * time.Second)
+	v, err := NewInLine("ad-1").
+		AdSystem("server", "1.0").
+		AdTitle("title").
+		Impression("https://example.com/imp").
+		Error("https://example.com/err").
+		Extension(Waterfall{FallbackIndex: 1}).
+		Linear(Duration(15*time.Second)).
+		CreativeID("crea-1", "ad-1").
+		SkipOffset(Offset{Duration: &d}).
+		MediaFile("https://example.com/ad.mp4", "video/mp4", 1280, 720).
+		Tracking(TRACK_START, "https://example.com/start").
+		ClickThrough("https://example.com/landing").
+		ClickTracking("https://example.com/click").
+		Build()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "3.0", v.Version)
+	if assert.Len(t, v.Ads, 1) && assert.NotNil(t, v.Ads[0].InLine) {
+		inline := v.Ads[0].InLine
+		assert.Equal(t, "ad-1", v.Ads[0].ID)
+		assert.Equal(t, "server", inline.AdSystem.Name)
+		assert.Equal(t, "https://example.com/imp", inline.Impressions[0].URI)
+		assert.Equal(t, EXT_WATERFALL, inline.Extensions[0].Type)
+		if assert.Len(t, inline.Creatives, 1) {
+			c := inline.Creatives[0]
+			assert.Equal(t, "crea-1", c.ID)
+			assert.Equal(t, Duration(15*time.Second), c.Linear.Duration)
+			assert.Equal(t, d, *c.Linear.SkipOffset.Duration)
+			assert.Equal(t, MediaFile{Delivery: "progressive", Type: "video/mp4", Width: 1280, Height: 720, URI: "https://example.com/ad.mp4"}, c.Linear.MediaFiles[0])
+			assert.Equal(t, []Tracking{{Event: TRACK_START, URI: "https://example.com/start"}}, c.Linear.TrackingEvents)
+			assert.Equal(t, "https://example.com/landing", c.Linear.VideoClicks.ClickThroughs[0].URI)
+			assert.Equal(t, "https://example.com/click", c.Linear.VideoClicks.ClickTrackings[0].URI)
+		}
+	}
+
+	_, err = xml.Marshal(v)
+	assert.NoError(t, err)
+}
+
+func TestInLineBuilderErrors(t *testing.T) {
+	_, err := NewInLine("1").AdSystem("s", "").AdTitle("t").Impression("i").MediaFile("m", "video/mp4", 1, 1).Build()
+	assert.EqualError(t, err, "MediaFile called before Linear")
+
+	_, err = NewInLine("1").AdTitle("t").Impression("i").Linear(0).Build()
+	assert.EqualError(t, err, "empty ad system")
+
+	_, err = NewInLine("1").AdSystem("s", "").AdTitle("t").Impression("i").Linear(0).Build()
+	assert.EqualError(t, err, "bad ad[0] bad creative[0] empty media")
+
+	_, err = NewInLine("1").AdSystem("s", "").AdTitle("t").Impression("i").Build()
+	assert.EqualError(t, err, "bad ad[0] empty creative")
+
+	_, err = NewInLine("1").AdSystem("s", "").AdTitle("t").Impression("i").Extension(struct{}{}).Build()
+	assert.EqualError(t, err, "unregistered extension value struct {}")
+}
+
+func TestWrapperBuilder(t *testing.T) {
+	v, err := NewWrapper("https://upstream.com/vast?cb=[CACHEBUSTING]").
+		ID("w-1").
+		AdSystem("server", "").
+		Impression("https://example.com/imp").
+		Tracking(TRACK_COMPLETE, "https://example.com/complete").
+		ClickTracking("https://example.com/click").
+		FallbackOnNoAd(true).
+		Build()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	wrapper := v.Ads[0].Wrapper
+	if assert.NotNil(t, wrapper) {
+		assert.Equal(t, "w-1", v.Ads[0].ID)
+		assert.Equal(t, "https://upstream.com/vast?cb=[CACHEBUSTING]", wrapper.VASTAdTagURI.CDATA)
+		assert.True(t, *wrapper.FallbackOnNoAd)
+		if assert.Len(t, wrapper.Creatives, 1) {
+			assert.Equal(t, TRACK_COMPLETE, wrapper.Creatives[0].Linear.TrackingEvents[0].Event)
+			assert.Equal(t, "https://example.com/click", wrapper.Creatives[0].Linear.VideoClicks.ClickTrackings[0].URI)
+		}
+	}
+
+	_, err = NewWrapper("upstream").AdSystem("server", "").Build()
+	assert.EqualError(t, err, "bad tag uri upstream")
+
+	_, err = NewWrapper("https://upstream.com").AdSystem("server", "").Tracking("", "https://example.com/t").Build()
+	assert.EqualError(t, err, "bad creative[0] bad track[0] empty event")
+}
@@ -0,0 +1,186 @@
+package vast
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+
+	xmlDeclEncoding = regexp.MustCompile(`\sencoding\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// single byte charsets, mapping bytes 0x80-0xFF to runes. Zero entries are
+// undefined and map to the C1 control with the same value.
+var charsets = map[string]*[128]rune{
+	"iso-8859-1":   &latin1,
+	"iso8859-1":    &latin1,
+	"iso_8859-1":   &latin1,
+	"latin1":       &latin1,
+	"latin-1":      &latin1,
+	"l1":           &latin1,
+	"windows-1252": &cp1252,
+	"cp1252":       &cp1252,
+	"windows-1251": &cp1251,
+	"cp1251":       &cp1251,
+}
+
+var latin1 [128]rune
+
+var cp1252 = [128]rune{
+	0x20AC, 0, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017D, 0,
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0, 0x017E, 0x0178,
+}
+
+var cp1251 = [128]rune{
+	0x0402, 0x0403, 0x201A, 0x0453, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x20AC, 0x2030, 0x0409, 0x2039, 0x040A, 0x040C, 0x040B, 0x040F,
+	0x0452, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0, 0x2122, 0x0459, 0x203A, 0x045A, 0x045C, 0x045B, 0x045F,
+	0x00A0, 0x040E, 0x045E, 0x0408, 0x00A4, 0x0490, 0x00A6, 0x00A7,
+	0x0401, 0x00A9, 0x0404, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x0407,
+	0x00B0, 0x00B1, 0x0406, 0x0456, 0x0491, 0x00B5, 0x00B6, 0x00B7,
+	0x0451, 0x2116, 0x0454, 0x00BB, 0x0458, 0x0405, 0x0455, 0x0457,
+}
+
+func init() {
+	// the rest of cp1252 is latin1, the rest of cp1251 is the cyrillic alphabet
+	for i := 0x20; i < 0x80; i++ {
+		cp1252[i] = rune(0x80 + i)
+	}
+	for i := 0x40; i < 0x80; i++ {
+		cp1251[i] = rune(0x0410 + i - 0x40)
+	}
+	for i := range latin1 {
+		latin1[i] = rune(0x80 + i)
+	}
+}
+
+// toUTF8 prepares a raw document for encoding/xml: it removes byte order marks,
+// blanks anything before the document, then transcodes the document to UTF-8.
+// It returns the original encoding of the document.
+func toUTF8(data []byte) ([]byte, string, error) {
+	enc := "UTF-8"
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		data = data[len(bomUTF8):]
+	case bytes.HasPrefix(data, bomUTF16LE), bytes.HasPrefix(data, bomUTF16BE):
+		le := data[0] == 0xFF
+		enc = "UTF-16BE"
+		if le {
+			enc = "UTF-16LE"
+		}
+		data = decodeUTF16(data[2:], le)
+	}
+
+	// leading whitespace or garbage is blanked rather than cut, so that
+	// offsets, lines and columns still match the document
+	start := documentStart(data)
+	if start > 0 {
+		data = append([]byte(nil), data...)
+		for i, b := range data[:start] {
+			if b != '\n' {
+				data[i] = ' '
+			}
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	if !bytes.HasPrefix(data[start:], []byte("<?xml")) {
+		return data, enc, nil
+	}
+	end := bytes.Index(data, []byte("?>"))
+	if end < 0 {
+		return data, enc, nil
+	}
+	loc := xmlDeclEncoding.FindSubmatchIndex(data[start:end])
+	if loc == nil {
+		return data, enc, nil
+	}
+	loc[2], loc[3] = loc[2]+start, loc[3]+start
+
+	declared := string(data[loc[2]+1 : loc[3]-1])
+	name := strings.ToLower(strings.TrimSpace(declared))
+	switch {
+	case name == "utf-8", name == "utf8", name == "us-ascii", name == "ascii":
+		if enc == "UTF-8" {
+			return data, declared, nil
+		}
+	case strings.HasPrefix(name, "utf-16"):
+		if enc == "UTF-8" {
+			return nil, "", fmt.Errorf("vast: %s declared without byte order mark", declared)
+		}
+	default:
+		table, ok := charsets[name]
+		if !ok {
+			return nil, "", fmt.Errorf("vast: unsupported encoding %s", declared)
+		}
+		enc = declared
+		data = decodeSingleByte(data, table)
+	}
+
+	// the document is now UTF-8, update the declaration accordingly
+	var buf bytes.Buffer
+	buf.Grow(len(data))
+	buf.Write(data[:loc[2]])
+	buf.WriteString(`"UTF-8"`)
+	buf.Write(data[loc[3]:])
+	return buf.Bytes(), enc, nil
+}
+
+// documentStart returns the offset of the XML declaration or of the <VAST>
+// element, whichever comes first, or of the first tag if there is neither
+func documentStart(data []byte) int {
+	start := -1
+	for _, tag := range [][]byte{[]byte("<?xml"), []byte("<VAST")} {
+		if i := bytes.Index(data, tag); i >= 0 && (start < 0 || i < start) {
+			start = i
+		}
+	}
+	if start < 0 {
+		start = bytes.IndexByte(data, '<')
+	}
+	return start
+}
+
+func decodeSingleByte(data []byte, table *[128]rune) []byte {
+	buf := make([]byte, 0, len(data)+len(data)/4)
+	for _, b := range data {
+		if b < 0x80 {
+			buf = append(buf, b)
+			continue
+		}
+		r := table[b-0x80]
+		if r == 0 {
+			r = rune(b)
+		}
+		buf = append(buf, string(r)...)
+	}
+	return buf
+}
+
+func decodeUTF16(data []byte, le bool) []byte {
+	u := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if le {
+			u = append(u, uint16(data[i])|uint16(data[i+1])<<8)
+		} else {
+			u = append(u, uint16(data[i])<<8|uint16(data[i+1]))
+		}
+	}
+	buf := make([]byte, 0, len(u))
+	for _, r := range utf16.Decode(u) {
+		buf = append(buf, string(r)...)
+	}
+	return buf
+}
@@ -0,0 +1,77 @@
+package vast
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeWindows1251(t *testing.T) {
+	// "Привет" in windows-1251
+	title := []byte{0xCF, 0xF0, 0xE8, 0xE2, 0xE5, 0xF2}
+	doc := []byte(`<?xml version="1.0" encoding="windows-1251"?><VAST version="3.0"><Ad><InLine><AdTitle><![CDATA[`)
+	doc = append(doc, title...)
+	doc = append(doc, `]]></AdTitle></InLine></Ad></VAST>`...)
+
+	res, err := Decode(bytes.NewReader(doc), DecodeOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "windows-1251", res.Encoding)
+		assert.Equal(t, "Привет", res.VAST.Ads[0].InLine.AdTitle.CDATA)
+	}
+}
+
+func TestDecodeLatin1(t *testing.T) {
+	doc := []byte("<?xml version='1.0' encoding='ISO-8859-1'?><VAST><Ad><InLine><AdTitle>Caf\xe9</AdTitle></InLine></Ad></VAST>")
+
+	res, err := Decode(bytes.NewReader(doc), DecodeOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ISO-8859-1", res.Encoding)
+		assert.Equal(t, "Café", res.VAST.Ads[0].InLine.AdTitle.CDATA)
+	}
+}
+
+func TestDecodeBOM(t *testing.T) {
+	doc := "\xef\xbb\xbf \r\n garbage<?xml version=\"1.0\" encoding=\"UTF-8\"?><VAST version=\"2.0\"/>"
+
+	res, err := Decode(strings.NewReader(doc), DecodeOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "UTF-8", res.Encoding)
+		assert.Equal(t, "2.0", res.VAST.Version)
+	}
+
+	// UTF-16 little endian with BOM
+	var b bytes.Buffer
+	b.Write([]byte{0xFF, 0xFE})
+	for _, r := range `<?xml version="1.0" encoding="UTF-16"?><VAST version="3.0"/>` {
+		b.Write([]byte{byte(r), 0})
+	}
+	res, err = Decode(&b, DecodeOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "UTF-16LE", res.Encoding)
+		assert.Equal(t, "3.0", res.VAST.Version)
+	}
+}
+
+func TestDecodeLeadingTags(t *testing.T) {
+	for _, doc := range []string{
+		"<html><body>\n<VAST version=\"2.0\">\n<Ad id=\"1\"/></VAST>",
+		"<br/> junk <?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<VAST version=\"2.0\">\n<Ad id=\"1\"/></VAST>",
+	} {
+		res, err := Decode(strings.NewReader(doc), DecodeOptions{Positions: true})
+		if !assert.NoError(t, err, doc) {
+			continue
+		}
+		assert.Equal(t, "2.0", res.VAST.Version)
+		p, ok := res.Positions.Lookup(&res.VAST.Ads[0])
+		if assert.True(t, ok) {
+			assert.Equal(t, "3:1", p.String())
+		}
+	}
+}
+
+func TestDecodeUnsupportedEncoding(t *testing.T) {
+	_, err := Decode(strings.NewReader(`<?xml version="1.0" encoding="EBCDIC"?><VAST/>`), DecodeOptions{})
+	assert.EqualError(t, err, "vast: unsupported encoding EBCDIC")
+}
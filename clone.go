@@ -0,0 +1,329 @@
+package vast
+
+// Clone returns a deep copy of the document: no slice, pointer, map or
+// extension payload is shared with the original, so the copy may be modified
+// (AddTracking, SetSecure, FilterSize, Validate, ...) while other goroutines
+// read or clone the original.
+func (v *VAST) Clone() *VAST {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	c.Errors = cloneCDATAs(v.Errors)
+	if v.Ads != nil {
+		c.Ads = make([]Ad, len(v.Ads))
+		for i := range v.Ads {
+			c.Ads[i] = *v.Ads[i].Clone()
+		}
+	}
+	return &c
+}
+
+// Clone returns a deep copy of the ad, see VAST.Clone.
+func (ad *Ad) Clone() *Ad {
+	if ad == nil {
+		return nil
+	}
+	c := *ad
+	c.InLine = ad.InLine.clone()
+	c.Wrapper = ad.Wrapper.clone()
+	return &c
+}
+
+func (inline *InLine) clone() *InLine {
+	if inline == nil {
+		return nil
+	}
+	c := *inline
+	c.AdSystem = inline.AdSystem.clone()
+	c.Impressions = cloneImpressions(inline.Impressions)
+	c.ViewableImpression = cloneViewables(inline.ViewableImpression)
+	c.Errors = cloneCDATAs(inline.Errors)
+	c.Extensions = cloneExtensions(inline.Extensions)
+	if inline.Categories != nil {
+		c.Categories = make([]Category, len(inline.Categories))
+		copy(c.Categories, inline.Categories)
+	}
+	if inline.Creatives != nil {
+		c.Creatives = make([]Creative, len(inline.Creatives))
+		for i := range inline.Creatives {
+			c.Creatives[i] = inline.Creatives[i].clone()
+		}
+	}
+	return &c
+}
+
+func (wrap *Wrapper) clone() *Wrapper {
+	if wrap == nil {
+		return nil
+	}
+	c := *wrap
+	c.AdSystem = wrap.AdSystem.clone()
+	c.Impressions = cloneImpressions(wrap.Impressions)
+	c.ViewableImpression = cloneViewables(wrap.ViewableImpression)
+	c.Errors = cloneCDATAs(wrap.Errors)
+	c.Extensions = cloneExtensions(wrap.Extensions)
+	c.FallbackOnNoAd = cloneBool(wrap.FallbackOnNoAd)
+	c.AllowMultipleAds = cloneBool(wrap.AllowMultipleAds)
+	c.FollowAdditionalWrappers = cloneBool(wrap.FollowAdditionalWrappers)
+	if wrap.Creatives != nil {
+		c.Creatives = make([]CreativeWrapper, len(wrap.Creatives))
+		for i := range wrap.Creatives {
+			c.Creatives[i] = wrap.Creatives[i].clone()
+		}
+	}
+	return &c
+}
+
+func (s *AdSystem) clone() *AdSystem {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
+
+func (creative Creative) clone() Creative {
+	c := creative
+	if l := creative.Linear; l != nil {
+		cl := *l
+		cl.SkipOffset = l.SkipOffset.clone()
+		cl.AdParameters = l.AdParameters.clone()
+		cl.Icons = l.Icons.clone()
+		cl.TrackingEvents = cloneTrackings(l.TrackingEvents)
+		cl.VideoClicks = l.VideoClicks.clone()
+		if l.MediaFiles != nil {
+			cl.MediaFiles = make([]MediaFile, len(l.MediaFiles))
+			copy(cl.MediaFiles, l.MediaFiles)
+		}
+		c.Linear = &cl
+	}
+	if ca := creative.CompanionAds; ca != nil {
+		cca := *ca
+		if ca.Companions != nil {
+			cca.Companions = make([]Companion, len(ca.Companions))
+			for i, comp := range ca.Companions {
+				comp.CompanionClickTracking = cloneCDATAs(comp.CompanionClickTracking)
+				comp.TrackingEvents = cloneTrackings(comp.TrackingEvents)
+				comp.AdParameters = comp.AdParameters.clone()
+				comp.StaticResource = comp.StaticResource.clone()
+				comp.HTMLResource = comp.HTMLResource.clone()
+				cca.Companions[i] = comp
+			}
+		}
+		c.CompanionAds = &cca
+	}
+	if n := creative.NonLinearAds; n != nil {
+		cn := *n
+		cn.TrackingEvents = cloneTrackings(n.TrackingEvents)
+		if n.NonLinears != nil {
+			cn.NonLinears = make([]NonLinear, len(n.NonLinears))
+			for i, nl := range n.NonLinears {
+				nl.MinSuggestedDuration = cloneDuration(nl.MinSuggestedDuration)
+				nl.NonLinearClickTracking = cloneCDATAs(nl.NonLinearClickTracking)
+				nl.AdParameters = nl.AdParameters.clone()
+				nl.StaticResource = nl.StaticResource.clone()
+				nl.HTMLResource = nl.HTMLResource.clone()
+				cn.NonLinears[i] = nl
+			}
+		}
+		c.NonLinearAds = &cn
+	}
+	return c
+}
+
+func (creative CreativeWrapper) clone() CreativeWrapper {
+	c := creative
+	if l := creative.Linear; l != nil {
+		cl := *l
+		cl.Icons = l.Icons.clone()
+		cl.TrackingEvents = cloneTrackings(l.TrackingEvents)
+		cl.VideoClicks = l.VideoClicks.clone()
+		c.Linear = &cl
+	}
+	if ca := creative.CompanionAds; ca != nil {
+		cca := *ca
+		if ca.Companions != nil {
+			cca.Companions = make([]CompanionWrapper, len(ca.Companions))
+			for i, comp := range ca.Companions {
+				comp.CompanionClickTracking = cloneCDATAs(comp.CompanionClickTracking)
+				comp.TrackingEvents = cloneTrackings(comp.TrackingEvents)
+				comp.AdParameters = comp.AdParameters.clone()
+				comp.StaticResource = comp.StaticResource.clone()
+				comp.HTMLResource = comp.HTMLResource.clone()
+				cca.Companions[i] = comp
+			}
+		}
+		c.CompanionAds = &cca
+	}
+	if n := creative.NonLinearAds; n != nil {
+		cn := *n
+		cn.TrackingEvents = cloneTrackings(n.TrackingEvents)
+		if n.NonLinears != nil {
+			cn.NonLinears = make([]NonLinearWrapper, len(n.NonLinears))
+			for i, nl := range n.NonLinears {
+				nl.MinSuggestedDuration = cloneDuration(nl.MinSuggestedDuration)
+				nl.TrackingEvents = cloneTrackings(nl.TrackingEvents)
+				nl.NonLinearClickTracking = cloneCDATAs(nl.NonLinearClickTracking)
+				cn.NonLinears[i] = nl
+			}
+		}
+		c.NonLinearAds = &cn
+	}
+	return c
+}
+
+func (icons *Icons) clone() *Icons {
+	if icons == nil {
+		return nil
+	}
+	c := *icons
+	if icons.Icon != nil {
+		c.Icon = make([]Icon, len(icons.Icon))
+		for i, icon := range icons.Icon {
+			icon.Offset.Duration = cloneDuration(icon.Offset.Duration)
+			icon.IconClickTrackings = cloneCDATAs(icon.IconClickTrackings)
+			icon.IconViewTrackings = cloneCDATAs(icon.IconViewTrackings)
+			icon.StaticResource = icon.StaticResource.clone()
+			icon.HTMLResource = icon.HTMLResource.clone()
+			c.Icon[i] = icon
+		}
+	}
+	return &c
+}
+
+func (clicks *VideoClicks) clone() *VideoClicks {
+	if clicks == nil {
+		return nil
+	}
+	return &VideoClicks{
+		ClickThroughs:  cloneVideoClicks(clicks.ClickThroughs),
+		ClickTrackings: cloneVideoClicks(clicks.ClickTrackings),
+		CustomClicks:   cloneVideoClicks(clicks.CustomClicks),
+	}
+}
+
+func (params *AdParameters) clone() *AdParameters {
+	if params == nil {
+		return nil
+	}
+	c := *params
+	return &c
+}
+
+func (res *StaticResource) clone() *StaticResource {
+	if res == nil {
+		return nil
+	}
+	c := *res
+	return &c
+}
+
+func (res *HTMLResource) clone() *HTMLResource {
+	if res == nil {
+		return nil
+	}
+	c := *res
+	return &c
+}
+
+func (o *Offset) clone() *Offset {
+	if o == nil {
+		return nil
+	}
+	c := *o
+	c.Duration = cloneDuration(o.Duration)
+	return &c
+}
+
+func cloneDuration(d *Duration) *Duration {
+	if d == nil {
+		return nil
+	}
+	c := *d
+	return &c
+}
+
+func cloneBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	c := *b
+	return &c
+}
+
+func cloneCDATAs(s []CDATAString) []CDATAString {
+	if s == nil {
+		return nil
+	}
+	c := make([]CDATAString, len(s))
+	copy(c, s)
+	return c
+}
+
+func cloneImpressions(s []Impression) []Impression {
+	if s == nil {
+		return nil
+	}
+	c := make([]Impression, len(s))
+	copy(c, s)
+	return c
+}
+
+func cloneViewables(s []Viewable) []Viewable {
+	if s == nil {
+		return nil
+	}
+	c := make([]Viewable, len(s))
+	copy(c, s)
+	return c
+}
+
+func cloneVideoClicks(s []VideoClick) []VideoClick {
+	if s == nil {
+		return nil
+	}
+	c := make([]VideoClick, len(s))
+	copy(c, s)
+	return c
+}
+
+func cloneTrackings(s []Tracking) []Tracking {
+	if s == nil {
+		return nil
+	}
+	c := make([]Tracking, len(s))
+	for i, t := range s {
+		t.Offset = t.Offset.clone()
+		c[i] = t
+	}
+	return c
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneExtensions(s []Extension) []Extension {
+	if s == nil {
+		return nil
+	}
+	c := make([]Extension, len(s))
+	for i, e := range s {
+		e.CustomTracking = cloneTrackings(e.CustomTracking)
+		if e.Data != nil {
+			e.Data = append(make([]byte, 0, len(e.Data)), e.Data...)
+		}
+		e.Attributes = cloneStringMap(e.Attributes)
+		e.Namespaces = cloneStringMap(e.Namespaces)
+		c[i] = e
+	}
+	return c
+}
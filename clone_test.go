@@ -0,0 +1,94 @@
+package vast
+
+import (
+	"encoding/xml"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClone(t *testing.T) {
+	for _, path := range []string{
+		"testdata/vast_inline_linear.xml",
+		"testdata/vast_inline_nonlinear.xml",
+		"testdata/vast_wrapper_linear_1.xml",
+		"testdata/vast_wrapper_nonlinear_1.xml",
+		"testdata/inline_extensions.xml",
+		"testdata/liverail-vast2-linear-companion.xml",
+	} {
+		v, _, _, err := loadFixture(path)
+		if !assert.NoError(t, err, path) {
+			continue
+		}
+		c := v.Clone()
+		assert.Equal(t, v, c, path)
+
+		before, _ := xml.Marshal(v)
+		c.AddTracking(Tracking{Event: TRACK_START, URI: "http://us.com/start"})
+		c.AddImpression(Impression{URI: "http://us.com/imp"})
+		c.AddError(CDATAString{"http://us.com/err"})
+		c.SetSecure(true)
+		c.Validate()
+		if inline := c.Ads[0].InLine; inline != nil && len(inline.Creatives) > 0 && inline.Creatives[0].Linear != nil {
+			c.FilterSize(640, 360)
+		}
+		after, _ := xml.Marshal(v)
+		assert.Equal(t, string(before), string(after), path)
+	}
+
+	var v *VAST
+	assert.Nil(t, v.Clone())
+}
+
+func TestCloneDeep(t *testing.T) {
+	skip := Offset{Duration: durationPtr(Duration(5e9))}
+	v := &VAST{Ads: []Ad{{InLine: &InLine{
+		Creatives: []Creative{{Linear: &Linear{
+			SkipOffset:     &skip,
+			TrackingEvents: []Tracking{{Event: "progress", Offset: &skip, URI: "http://a"}},
+			MediaFiles:     []MediaFile{{URI: "http://a.mp4", Type: "video/mp4"}},
+		}}},
+		Extensions: []Extension{{Type: "x", Data: []byte("<a/>"), Attributes: map[string]string{"k": "v"}}},
+	}}}}
+
+	c := v.Clone()
+	cl := c.Ads[0].InLine.Creatives[0].Linear
+	*cl.SkipOffset.Duration = 0
+	*cl.TrackingEvents[0].Offset.Duration = 0
+	cl.MediaFiles[0].URI = ""
+	ext := &c.Ads[0].InLine.Extensions[0]
+	ext.Data[0] = '!'
+	ext.Attributes["k"] = "changed"
+
+	l := v.Ads[0].InLine.Creatives[0].Linear
+	assert.Equal(t, Duration(5e9), *l.SkipOffset.Duration)
+	assert.Equal(t, Duration(5e9), *l.TrackingEvents[0].Offset.Duration)
+	assert.Equal(t, "http://a.mp4", l.MediaFiles[0].URI)
+	assert.Equal(t, "<a/>", string(v.Ads[0].InLine.Extensions[0].Data))
+	assert.Equal(t, "v", v.Ads[0].InLine.Extensions[0].Attributes["k"])
+}
+
+func TestCloneConcurrent(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_inline_linear.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := v.Clone()
+			c.SetSecure(true)
+			c.Validate()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "http://myTrackingURL/impression", v.Ads[0].InLine.Impressions[0].URI)
+}
+
+func durationPtr(d Duration) *Duration {
+	return &d
+}
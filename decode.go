@@ -0,0 +1,565 @@
+package vast
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DecodeOptions configures Decode.
+type DecodeOptions struct {
+	// Strict rejects malformed durations, offsets and enumerated values. When
+	// false, the decoder coerces them to the closest valid value and reports
+	// what it did as warnings.
+	Strict bool
+	// Positions records the source position of the main elements of the
+	// document in DecodeResult.Positions.
+	Positions bool
+	// Limits, when set, bounds the size and complexity of the document and
+	// rejects DOCTYPE and entity declarations, see DecodeSafe.
+	Limits *Limits
+}
+
+// DecodeResult is the outcome of Decode.
+type DecodeResult struct {
+	VAST *VAST
+	// Values coerced by the lenient decoder, in document order
+	Warnings []Warning
+	// Source positions, only set when DecodeOptions.Positions is. Offsets and
+	// columns count bytes of the document after its conversion to UTF-8, not of
+	// the original bytes. Lines are those of the original document.
+	Positions *SourceMap
+	// Encoding of the original document, e.g. UTF-8 or windows-1251
+	Encoding string
+	// The document is valid but holds no ad, see VAST.IsNoAd. Invalid
+	// documents are reported by the error of Decode instead.
+	NoAd bool
+}
+
+// Warning describes a malformed value found while decoding.
+type Warning struct {
+	// Position of the element holding the value (1-based)
+	Line   int
+	Column int
+	// Element holding the value, followed by @attr for attributes
+	Element string
+	Value   string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%d:%d: %s %q: %s", w.Line, w.Column, w.Element, w.Value, w.Message)
+}
+
+// ValueError is returned by Decode in strict mode for the first malformed value.
+type ValueError struct {
+	Warning
+}
+
+func (e *ValueError) Error() string {
+	return "invalid value at " + e.Warning.String()
+}
+
+// Decode reads a VAST document from r. Unlike xml.Unmarshal it validates the
+// durations, offsets and enumerated attributes of the document: in strict mode
+// the first malformed value is returned as a *ValueError, otherwise it is
+// coerced and reported in DecodeResult.Warnings. A document whose root is not
+// <VAST> is an error, a valid document without ads sets DecodeResult.NoAd.
+//
+// Documents with a byte order mark, leading garbage or a declared UTF-16,
+// ISO-8859-1, windows-1252 or windows-1251 encoding are converted to UTF-8
+// before decoding.
+func Decode(r io.Reader, opts DecodeOptions) (*DecodeResult, error) {
+	var max int64
+	if opts.Limits != nil {
+		max = opts.Limits.MaxSize
+	}
+	data, err := readLimited(r, max)
+	if err != nil {
+		return nil, err
+	}
+	data, enc, err := toUTF8(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newScanner(data, opts)
+	if err := s.scan(); err != nil {
+		return nil, err
+	}
+
+	var v VAST
+	if err := xml.Unmarshal(s.output(), &v); err != nil {
+		return nil, err
+	}
+	if s.sources != nil {
+		s.sources.bind(&v)
+	}
+
+	return &DecodeResult{VAST: &v, Warnings: s.warnings, Positions: s.sources, Encoding: enc, NoAd: v.IsNoAd()}, nil
+}
+
+// values of the enumerated attributes checked by the decoder
+var (
+	deliveryValues = []string{"streaming", "progressive"}
+	requiredValues = []string{"all", "any", "none"}
+	eventValues    = []string{
+		"creativeView", "start", "firstQuartile", "midpoint", "thirdQuartile",
+		"complete", "mute", "unmute", "pause", "rewind", "resume", "fullscreen",
+		"exitFullscreen", "expand", "collapse", "acceptInvitation",
+		"acceptInvitationLinear", "closeLinear", "close", "skip", "progress",
+		"loaded", "playerExpand", "playerCollapse", "notUsed",
+		"otherAdInteraction", "adExpand", "adCollapse", "minimize",
+		"overlayViewDuration", "interactiveStart",
+	}
+)
+
+// edit replaces data[start:end] in the scanned document
+type edit struct {
+	start, end int64
+	data       []byte
+}
+
+// scanner walks the raw tokens of a document before it is unmarshaled,
+// checking and fixing the values encoding/xml would otherwise reject.
+type scanner struct {
+	data     []byte
+	opts     DecodeOptions
+	lines    []int64
+	stack    []string
+	edits    []edit
+	warnings []Warning
+
+	// path segments of the open elements and counts of their children
+	segs     []string
+	counts   []map[string]int
+	sources  *SourceMap
+	elements int
+
+	// text of the current <Duration> element
+	text      bytes.Buffer
+	textStart int64
+	textEnd   int64
+	textPos   int64
+}
+
+func newScanner(data []byte, opts DecodeOptions) *scanner {
+	s := &scanner{data: data, opts: opts, lines: []int64{0}}
+	s.counts = []map[string]int{{}}
+	if opts.Positions {
+		s.sources = newSourceMap()
+	}
+	for i, b := range data {
+		if b == '\n' {
+			s.lines = append(s.lines, int64(i+1))
+		}
+	}
+	return s
+}
+
+// position returns the 1-based line and column of a byte offset
+func (s *scanner) position(off int64) (int, int) {
+	i := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > off }) - 1
+	return i + 1, int(off-s.lines[i]) + 1
+}
+
+func (s *scanner) scan() error {
+	dec := xml.NewDecoder(bytes.NewReader(s.data))
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		end := dec.InputOffset()
+
+		if err := s.checkLimits(tok, start); err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(s.stack) == 0 && t.Name.Local != "VAST" {
+				return fmt.Errorf("expected element <VAST> but have <%s>", t.Name.Local)
+			}
+			s.push(t.Name.Local, start)
+			if err := s.startElement(t, start, end); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if err := s.endElement(); err != nil {
+				return err
+			}
+			s.pop()
+		case xml.CharData:
+			if s.parent(0) == "Duration" && s.parent(1) == "Linear" {
+				if s.text.Len() == 0 && s.textEnd == 0 {
+					s.textStart = start
+				}
+				s.text.Write(t)
+				s.textEnd = end
+			}
+		}
+	}
+}
+
+// checkLimits enforces opts.Limits on a token starting at the given offset
+func (s *scanner) checkLimits(tok xml.Token, off int64) error {
+	l := s.opts.Limits
+	if l == nil {
+		return nil
+	}
+	fail := func(limit string, max int) error {
+		line, col := s.position(off)
+		return &LimitError{Limit: limit, Max: int64(max), Line: line, Column: col}
+	}
+
+	switch t := tok.(type) {
+	case xml.StartElement:
+		s.elements++
+		if l.MaxDepth > 0 && len(s.stack)+1 > l.MaxDepth {
+			return fail("depth", l.MaxDepth)
+		}
+		if l.MaxElements > 0 && s.elements > l.MaxElements {
+			return fail("elements", l.MaxElements)
+		}
+		if l.MaxAttributes > 0 && len(t.Attr) > l.MaxAttributes {
+			return fail("attributes", l.MaxAttributes)
+		}
+	case xml.CharData:
+		if l.MaxCDATA > 0 && len(t) > l.MaxCDATA {
+			return fail("cdata", l.MaxCDATA)
+		}
+	case xml.Directive:
+		d := bytes.TrimSpace(t)
+		if bytes.HasPrefix(d, []byte("DOCTYPE")) || bytes.HasPrefix(d, []byte("ENTITY")) {
+			return fail("doctype", 0)
+		}
+	}
+	return nil
+}
+
+// push opens an element starting at the given offset
+func (s *scanner) push(name string, off int64) {
+	counts := s.counts[len(s.counts)-1]
+	s.stack = append(s.stack, name)
+	s.segs = append(s.segs, pathElem(name, counts[name]))
+	s.counts = append(s.counts, map[string]int{})
+	counts[name]++
+
+	if s.sources != nil && positionedElements[name] {
+		line, col := s.position(off)
+		s.sources.paths[s.path()] = Position{Offset: off, Line: line, Column: col}
+	}
+}
+
+// pop closes the current element
+func (s *scanner) pop() {
+	if len(s.stack) > 0 {
+		s.stack = s.stack[:len(s.stack)-1]
+		s.segs = s.segs[:len(s.segs)-1]
+		s.counts = s.counts[:len(s.counts)-1]
+	}
+}
+
+// path returns the path of the current element, without the root element
+func (s *scanner) path() string {
+	if len(s.segs) < 2 {
+		return ""
+	}
+	return joinPath("", s.segs[1:]...)
+}
+
+// parent returns the name of the n-th enclosing element, 0 being the current one
+func (s *scanner) parent(n int) string {
+	if i := len(s.stack) - 1 - n; i >= 0 {
+		return s.stack[i]
+	}
+	return ""
+}
+
+// inExtension reports whether the current element is part of an extension
+// payload, returning the path of the element relative to the extension.
+func (s *scanner) inExtension() (string, bool) {
+	for i := len(s.stack) - 2; i >= 0; i-- {
+		if s.stack[i] == "Extension" || s.stack[i] == "CreativeExtension" {
+			return strings.Join(s.stack[i+1:], "/"), true
+		}
+	}
+	return "", false
+}
+
+func (s *scanner) startElement(t xml.StartElement, start, end int64) error {
+	name := t.Name.Local
+	if name == "Duration" && s.parent(1) == "Linear" {
+		s.text.Reset()
+		s.textStart, s.textEnd, s.textPos = 0, 0, start
+	}
+
+	tag := s.data[start:end]
+	changed := false
+	for _, attr := range t.Attr {
+		var fixed string
+		var msgs []string
+		var err error
+		enum := false
+
+		rel, ext := s.inExtension()
+		switch {
+		case ext && !(rel == "CustomTracking/Tracking" && attr.Name.Local == "offset"):
+			continue
+		case name == "Linear" && attr.Name.Local == "skipoffset",
+			name == "Tracking" && attr.Name.Local == "offset",
+			name == "Icon" && attr.Name.Local == "offset":
+			fixed, msgs, err = fixOffset(attr.Value)
+		case name == "Icon" && attr.Name.Local == "duration",
+			name == "NonLinear" && attr.Name.Local == "minSuggestedDuration":
+			fixed, msgs, err = fixDuration(attr.Value, true)
+		case name == "MediaFile" && attr.Name.Local == "delivery":
+			fixed, msgs, err = fixEnum(attr.Value, deliveryValues)
+			enum = true
+		case name == "CompanionAds" && attr.Name.Local == "required":
+			fixed, msgs, err = fixEnum(attr.Value, requiredValues)
+			enum = true
+		case name == "Tracking" && attr.Name.Local == "event" && s.parent(1) == "TrackingEvents":
+			fixed, msgs, err = fixEnum(attr.Value, eventValues)
+			enum = true
+		default:
+			continue
+		}
+
+		element := name + "@" + attr.Name.Local
+		if err != nil {
+			if s.opts.Strict {
+				return s.fail(start, element, attr.Value, err.Error())
+			}
+			if enum {
+				// unknown enumerated values are kept as is
+				s.warn(start, element, attr.Value, err.Error())
+				continue
+			}
+			s.warn(start, element, attr.Value, err.Error()+", attribute dropped")
+			tag = setAttr(tag, attr.Name, nil)
+			changed = true
+			continue
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+		if s.opts.Strict {
+			return s.fail(start, element, attr.Value, msgs[0])
+		}
+		s.warn(start, element, attr.Value, strings.Join(msgs, ", "))
+		tag = setAttr(tag, attr.Name, &fixed)
+		changed = true
+	}
+
+	if changed {
+		s.edits = append(s.edits, edit{start: start, end: end, data: tag})
+	}
+	return nil
+}
+
+func (s *scanner) endElement() error {
+	if s.parent(0) != "Duration" || s.parent(1) != "Linear" {
+		return nil
+	}
+
+	value := s.text.String()
+	fixed, msgs, err := fixDuration(value, false)
+	if err != nil {
+		if s.opts.Strict {
+			return s.fail(s.textPos, "Duration", value, err.Error())
+		}
+		s.warn(s.textPos, "Duration", value, err.Error()+", using 00:00:00")
+		fixed, msgs = "00:00:00", nil
+	} else if len(msgs) > 0 {
+		if s.opts.Strict {
+			return s.fail(s.textPos, "Duration", value, msgs[0])
+		}
+		s.warn(s.textPos, "Duration", value, strings.Join(msgs, ", "))
+	} else {
+		return nil
+	}
+
+	// an empty element already decodes to zero
+	if s.textEnd > 0 && fixed != strings.TrimSpace(value) {
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(fixed))
+		s.edits = append(s.edits, edit{start: s.textStart, end: s.textEnd, data: buf.Bytes()})
+	}
+	return nil
+}
+
+func (s *scanner) warning(off int64, element, value, msg string) Warning {
+	line, col := s.position(off)
+	return Warning{Line: line, Column: col, Element: element, Value: value, Message: msg}
+}
+
+func (s *scanner) warn(off int64, element, value, msg string) {
+	s.warnings = append(s.warnings, s.warning(off, element, value, msg))
+}
+
+func (s *scanner) fail(off int64, element, value, msg string) error {
+	return &ValueError{s.warning(off, element, value, msg)}
+}
+
+// output returns the document with all the edits applied
+func (s *scanner) output() []byte {
+	if len(s.edits) == 0 {
+		return s.data
+	}
+	var buf bytes.Buffer
+	var last int64
+	for _, e := range s.edits {
+		buf.Write(s.data[last:e.start])
+		buf.Write(e.data)
+		last = e.end
+	}
+	buf.Write(s.data[last:])
+	return buf.Bytes()
+}
+
+// setAttr replaces the value of an attribute in a raw start tag, removing the
+// attribute if value is nil.
+func setAttr(tag []byte, name xml.Name, value *string) []byte {
+	qname := name.Local
+	if name.Space != "" {
+		qname = name.Space + ":" + qname
+	}
+	re := regexp.MustCompile(`\s` + regexp.QuoteMeta(qname) + `\s*=\s*("[^"]*"|'[^']*')`)
+	loc := re.FindIndex(tag)
+	if loc == nil {
+		return tag
+	}
+
+	var buf bytes.Buffer
+	buf.Write(tag[:loc[0]])
+	if value != nil {
+		buf.WriteString(" " + qname + `="`)
+		xml.EscapeText(&buf, []byte(*value))
+		buf.WriteString(`"`)
+	}
+	buf.Write(tag[loc[1]:])
+	return buf.Bytes()
+}
+
+// fixDuration checks a hh:mm:ss[.mmm] value, returning its canonical form and
+// the list of coercions needed to get it. Surrounding whitespace is only
+// reported for attribute values.
+func fixDuration(value string, attr bool) (string, []string, error) {
+	var msgs []string
+	s := strings.TrimSpace(value)
+	if attr && s != value {
+		msgs = append(msgs, "stray whitespace")
+	}
+	if s == "" || strings.ToLower(s) == "undefined" {
+		return "00:00:00", append(msgs, "undefined duration"), nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) == 2 {
+		parts = append(parts, "00")
+		msgs = append(msgs, "missing seconds")
+	}
+	if len(parts) != 3 {
+		return "", nil, fmt.Errorf("invalid duration")
+	}
+
+	var ms int64
+	if i := strings.IndexByte(parts[2], '.'); i >= 0 {
+		frac := parts[2][i+1:]
+		parts[2] = parts[2][:i]
+		if len(frac) > 3 {
+			frac = frac[:3]
+			msgs = append(msgs, "milliseconds truncated")
+		}
+		n, err := strconv.ParseUint(frac, 10, 32)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid duration")
+		}
+		for i := len(frac); i < 3; i++ {
+			n *= 10
+		}
+		ms = int64(n)
+	}
+
+	var n [3]int64
+	for i, p := range parts {
+		v, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid duration")
+		}
+		n[i] = int64(v)
+	}
+	if n[1] > 59 || n[2] > 59 {
+		msgs = append(msgs, "minutes or seconds above 59")
+	}
+
+	d := Duration(n[0])*Duration(time.Hour) +
+		Duration(n[1])*Duration(time.Minute) +
+		Duration(n[2])*Duration(time.Second) +
+		Duration(ms)*Duration(time.Millisecond)
+	b, _ := d.MarshalText()
+	return string(b), msgs, nil
+}
+
+// fixOffset checks a percentage or duration offset, see fixDuration.
+func fixOffset(value string) (string, []string, error) {
+	s := strings.TrimSpace(value)
+	if !strings.HasSuffix(s, "%") {
+		fixed, msgs, err := fixDuration(value, true)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid offset")
+		}
+		return fixed, msgs, nil
+	}
+
+	var msgs []string
+	if s != value {
+		msgs = append(msgs, "stray whitespace")
+	}
+	p, err := strconv.ParseFloat(strings.TrimSpace(s[:len(s)-1]), 64)
+	if err != nil || math.IsNaN(p) {
+		return "", nil, fmt.Errorf("invalid offset")
+	}
+	if p != math.Trunc(p) {
+		p = math.Round(p)
+		msgs = append(msgs, "fractional percentage rounded")
+	}
+	if p < 0 || p > 100 {
+		p = math.Max(0, math.Min(100, p))
+		msgs = append(msgs, "percentage out of range")
+	}
+	return fmt.Sprintf("%d%%", int(p)), msgs, nil
+}
+
+// fixEnum checks value is one of the allowed values.
+func fixEnum(value string, allowed []string) (string, []string, error) {
+	s := strings.TrimSpace(value)
+	var msgs []string
+	if s != value {
+		msgs = append(msgs, "stray whitespace")
+	}
+	for _, a := range allowed {
+		if s == a {
+			return a, msgs, nil
+		}
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(s, a) {
+			return a, append(msgs, "wrong case"), nil
+		}
+	}
+	return "", nil, fmt.Errorf("unknown value")
+}
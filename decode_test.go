@@ -0,0 +1,125 @@
+package vast
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const malformedVAST = `<VAST version="3.0">
+  <Ad id="1">
+    <InLine>
+      <Creatives>
+        <Creative>
+          <Linear skipoffset=" 12.5% ">
+            <Duration>00:30</Duration>
+            <TrackingEvents>
+              <Tracking event="Start"><![CDATA[http://track/start]]></Tracking>
+              <Tracking event="progress" offset="00:00:75"><![CDATA[http://track/progress]]></Tracking>
+            </TrackingEvents>
+            <MediaFiles>
+              <MediaFile delivery="progressive" type="video/mp4" width="640" height="360"><![CDATA[http://media/1.mp4]]></MediaFile>
+            </MediaFiles>
+          </Linear>
+        </Creative>
+      </Creatives>
+    </InLine>
+  </Ad>
+</VAST>`
+
+func TestDecodeLenient(t *testing.T) {
+	res, err := Decode(strings.NewReader(malformedVAST), DecodeOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	linear := res.VAST.Ads[0].InLine.Creatives[0].Linear
+	if assert.NotNil(t, linear.SkipOffset) {
+		assert.Nil(t, linear.SkipOffset.Duration)
+		assert.Equal(t, float32(0.13), linear.SkipOffset.Percent)
+	}
+	assert.Equal(t, Duration(30*time.Minute), linear.Duration)
+	assert.Equal(t, "start", linear.TrackingEvents[0].Event)
+	if assert.NotNil(t, linear.TrackingEvents[1].Offset) {
+		assert.Equal(t, Duration(75*time.Second), *linear.TrackingEvents[1].Offset.Duration)
+	}
+
+	if assert.Len(t, res.Warnings, 4) {
+		assert.Equal(t, Warning{Line: 6, Column: 11, Element: "Linear@skipoffset", Value: " 12.5% ", Message: "stray whitespace, fractional percentage rounded"}, res.Warnings[0])
+		assert.Equal(t, Warning{Line: 7, Column: 13, Element: "Duration", Value: "00:30", Message: "missing seconds"}, res.Warnings[1])
+		assert.Equal(t, Warning{Line: 9, Column: 15, Element: "Tracking@event", Value: "Start", Message: "wrong case"}, res.Warnings[2])
+		assert.Equal(t, "10:15: Tracking@offset \"00:00:75\": minutes or seconds above 59", res.Warnings[3].String())
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	_, err := Decode(strings.NewReader(malformedVAST), DecodeOptions{Strict: true})
+	if assert.IsType(t, &ValueError{}, err) {
+		assert.Equal(t, "Linear@skipoffset", err.(*ValueError).Element)
+	}
+	assert.EqualError(t, err, `invalid value at 6:11: Linear@skipoffset " 12.5% ": stray whitespace`)
+
+	f, err := os.Open("testdata/vast_inline_linear.xml")
+	if assert.NoError(t, err) {
+		defer f.Close()
+		res, err := Decode(f, DecodeOptions{Strict: true})
+		if assert.NoError(t, err) {
+			assert.Empty(t, res.Warnings)
+			assert.Equal(t, "601364", res.VAST.Ads[0].ID)
+		}
+	}
+}
+
+func TestDecodeLongDuration(t *testing.T) {
+	doc := `<VAST version="3.0"><Ad><InLine><Creatives><Creative><Linear><Duration>100:00:00</Duration>` +
+		`</Linear></Creative></Creatives></InLine></Ad></VAST>`
+	for _, strict := range []bool{false, true} {
+		res, err := Decode(strings.NewReader(doc), DecodeOptions{Strict: strict})
+		if assert.NoError(t, err) {
+			assert.Empty(t, res.Warnings)
+			assert.Equal(t, Duration(100*time.Hour), res.VAST.Ads[0].InLine.Creatives[0].Linear.Duration)
+		}
+	}
+}
+
+func TestDecodeUndefinedDuration(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/vast_inline_linear-duration_undefined.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := Decode(bytes.NewReader(b), DecodeOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, Duration(0), res.VAST.Ads[0].InLine.Creatives[0].Linear.Duration)
+		if assert.Len(t, res.Warnings, 1) {
+			assert.Equal(t, "undefined duration", res.Warnings[0].Message)
+		}
+	}
+
+	_, err = Decode(bytes.NewReader(b), DecodeOptions{Strict: true})
+	assert.IsType(t, &ValueError{}, err)
+}
+
+func TestDecodeInvalidValues(t *testing.T) {
+	doc := `<VAST><Ad><InLine><Creatives><Creative><Linear skipoffset="soon"><Duration>abc</Duration>` +
+		`<MediaFiles><MediaFile delivery="download" type="video/mp4"><![CDATA[http://media/1.mp4]]></MediaFile></MediaFiles>` +
+		`</Linear></Creative></Creatives></InLine></Ad></VAST>`
+
+	res, err := Decode(strings.NewReader(doc), DecodeOptions{})
+	if assert.NoError(t, err) {
+		linear := res.VAST.Ads[0].InLine.Creatives[0].Linear
+		assert.Nil(t, linear.SkipOffset)
+		assert.Equal(t, Duration(0), linear.Duration)
+		assert.Equal(t, "download", linear.MediaFiles[0].Delivery)
+		if assert.Len(t, res.Warnings, 3) {
+			assert.Equal(t, "invalid offset, attribute dropped", res.Warnings[0].Message)
+			assert.Equal(t, "invalid duration, using 00:00:00", res.Warnings[1].Message)
+			assert.Equal(t, "unknown value", res.Warnings[2].Message)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package vast
+
+// DedupeOptions tunes Dedupe.
+type DedupeOptions struct {
+	// Ignore cache busting query parameters when comparing URIs, see
+	// DiffOptions
+	IgnoreCacheBusters bool
+	CacheBusters       []string
+}
+
+// Dedupe removes the repeated pixels of the document so each fires once:
+// impressions, viewable impressions, error pixels, trackers (keyed by event,
+// offset and URI) and click trackers. Pixels are compared within their list,
+// ignoring surrounding whitespace and, if enabled, cache busting parameters.
+// The first of duplicates is kept. It returns the number of pixels removed.
+func (v *VAST) Dedupe(opts DedupeOptions) int {
+	d := &deduper{uriKey: newURIKey(opts.IgnoreCacheBusters, opts.CacheBusters)}
+
+	v.Errors = d.cdatas(v.Errors)
+	for _, ad := range v.Ads {
+		if inline := ad.InLine; inline != nil {
+			inline.Impressions = d.impressions(inline.Impressions)
+			inline.ViewableImpression = d.viewables(inline.ViewableImpression)
+			inline.Errors = d.cdatas(inline.Errors)
+			for _, c := range inline.Creatives {
+				if l := c.Linear; l != nil {
+					l.TrackingEvents = d.trackings(l.TrackingEvents)
+					d.videoClicks(l.VideoClicks)
+					d.icons(l.Icons)
+				}
+				if n := c.NonLinearAds; n != nil {
+					n.TrackingEvents = d.trackings(n.TrackingEvents)
+					for i := range n.NonLinears {
+						n.NonLinears[i].NonLinearClickTracking = d.cdatas(n.NonLinears[i].NonLinearClickTracking)
+					}
+				}
+				if ca := c.CompanionAds; ca != nil {
+					for i := range ca.Companions {
+						comp := &ca.Companions[i]
+						comp.TrackingEvents = d.trackings(comp.TrackingEvents)
+						comp.CompanionClickTracking = d.cdatas(comp.CompanionClickTracking)
+					}
+				}
+			}
+			for i := range inline.Extensions {
+				inline.Extensions[i].CustomTracking = d.trackings(inline.Extensions[i].CustomTracking)
+			}
+		} else if wrap := ad.Wrapper; wrap != nil {
+			wrap.Impressions = d.impressions(wrap.Impressions)
+			wrap.ViewableImpression = d.viewables(wrap.ViewableImpression)
+			wrap.Errors = d.cdatas(wrap.Errors)
+			for _, c := range wrap.Creatives {
+				if l := c.Linear; l != nil {
+					l.TrackingEvents = d.trackings(l.TrackingEvents)
+					d.videoClicks(l.VideoClicks)
+					d.icons(l.Icons)
+				}
+				if n := c.NonLinearAds; n != nil {
+					n.TrackingEvents = d.trackings(n.TrackingEvents)
+					for i := range n.NonLinears {
+						nl := &n.NonLinears[i]
+						nl.TrackingEvents = d.trackings(nl.TrackingEvents)
+						nl.NonLinearClickTracking = d.cdatas(nl.NonLinearClickTracking)
+					}
+				}
+				if ca := c.CompanionAds; ca != nil {
+					for i := range ca.Companions {
+						comp := &ca.Companions[i]
+						comp.TrackingEvents = d.trackings(comp.TrackingEvents)
+						comp.CompanionClickTracking = d.cdatas(comp.CompanionClickTracking)
+					}
+				}
+			}
+			for i := range wrap.Extensions {
+				wrap.Extensions[i].CustomTracking = d.trackings(wrap.Extensions[i].CustomTracking)
+			}
+		}
+	}
+	return d.removed
+}
+
+type deduper struct {
+	uriKey
+	removed int
+}
+
+// seen reports whether key was already seen in the list, empty keys are
+// never duplicates
+func (d *deduper) seen(seen map[string]bool, key string) bool {
+	if key == "" || !seen[key] {
+		seen[key] = true
+		return false
+	}
+	d.removed++
+	return true
+}
+
+func (d *deduper) cdatas(s []CDATAString) []CDATAString {
+	seen := map[string]bool{}
+	f := s[:0]
+	for _, c := range s {
+		if !d.seen(seen, d.uri(c.CDATA)) {
+			f = append(f, c)
+		}
+	}
+	return f
+}
+
+func (d *deduper) impressions(s []Impression) []Impression {
+	seen := map[string]bool{}
+	f := s[:0]
+	for _, imp := range s {
+		if !d.seen(seen, d.uri(imp.URI)) {
+			f = append(f, imp)
+		}
+	}
+	return f
+}
+
+func (d *deduper) viewables(s []Viewable) []Viewable {
+	seen := map[string]bool{}
+	f := s[:0]
+	for _, imp := range s {
+		if !d.seen(seen, d.uri(imp.URI)) {
+			f = append(f, imp)
+		}
+	}
+	return f
+}
+
+func (d *deduper) trackings(s []Tracking) []Tracking {
+	seen := map[string]bool{}
+	f := s[:0]
+	for _, t := range s {
+		key := d.uri(t.URI)
+		if key != "" {
+			key = t.Event + "@" + offsetText(t.Offset) + " " + key
+		}
+		if !d.seen(seen, key) {
+			f = append(f, t)
+		}
+	}
+	return f
+}
+
+func (d *deduper) clicks(s []VideoClick) []VideoClick {
+	seen := map[string]bool{}
+	f := s[:0]
+	for _, c := range s {
+		if !d.seen(seen, d.uri(c.URI)) {
+			f = append(f, c)
+		}
+	}
+	return f
+}
+
+func (d *deduper) videoClicks(clicks *VideoClicks) {
+	if clicks != nil {
+		clicks.ClickTrackings = d.clicks(clicks.ClickTrackings)
+		clicks.CustomClicks = d.clicks(clicks.CustomClicks)
+	}
+}
+
+func (d *deduper) icons(icons *Icons) {
+	if icons != nil {
+		for i := range icons.Icon {
+			icons.Icon[i].IconClickTrackings = d.cdatas(icons.Icon[i].IconClickTrackings)
+			icons.Icon[i].IconViewTrackings = d.cdatas(icons.Icon[i].IconViewTrackings)
+		}
+	}
+}
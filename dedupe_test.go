@@ -0,0 +1,54 @@
+package vast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dedupeVAST() *VAST {
+	five := Offset{Percent: 0.05}
+	return &VAST{
+		Errors: []CDATAString{{"http://err"}, {" http://err "}},
+		Ads: []Ad{{Wrapper: &Wrapper{
+			Impressions: []Impression{
+				{URI: "http://imp?id=1&cb=1"},
+				{URI: "http://imp?id=1&cb=2"},
+				{URI: "http://imp?id=1&cb=1"},
+				{URI: ""},
+				{URI: ""},
+			},
+			ViewableImpression: []Viewable{{URI: "http://view"}, {URI: "http://view"}},
+			Creatives: []CreativeWrapper{{Linear: &LinearWrapper{
+				TrackingEvents: []Tracking{
+					{Event: TRACK_START, URI: "http://t"},
+					{Event: TRACK_COMPLETE, URI: "http://t"},
+					{Event: TRACK_START, URI: "http://t"},
+					{Event: "progress", Offset: &five, URI: "http://t"},
+					{Event: "progress", URI: "http://t"},
+				},
+				VideoClicks: &VideoClicks{ClickTrackings: []VideoClick{{URI: "http://c"}, {URI: "http://c\n"}}},
+			}}},
+		}}},
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	v := dedupeVAST()
+	assert.Equal(t, 5, v.Dedupe(DedupeOptions{}))
+
+	wrap := v.Ads[0].Wrapper
+	assert.Equal(t, []CDATAString{{"http://err"}}, v.Errors)
+	assert.Equal(t, []Impression{{URI: "http://imp?id=1&cb=1"}, {URI: "http://imp?id=1&cb=2"}, {URI: ""}, {URI: ""}}, wrap.Impressions)
+	assert.Len(t, wrap.ViewableImpression, 1)
+	assert.Len(t, wrap.Creatives[0].Linear.TrackingEvents, 4)
+	assert.Equal(t, []VideoClick{{URI: "http://c"}}, wrap.Creatives[0].Linear.VideoClicks.ClickTrackings)
+
+	assert.Equal(t, 0, v.Dedupe(DedupeOptions{}))
+}
+
+func TestDedupeCacheBusters(t *testing.T) {
+	v := dedupeVAST()
+	assert.Equal(t, 6, v.Dedupe(DedupeOptions{IgnoreCacheBusters: true}))
+	assert.Equal(t, []Impression{{URI: "http://imp?id=1&cb=1"}, {URI: ""}, {URI: ""}}, v.Ads[0].Wrapper.Impressions)
+}
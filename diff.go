@@ -0,0 +1,679 @@
+package vast
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Kinds of Change
+const (
+	DIFF_ADDED   = "added"
+	DIFF_REMOVED = "removed"
+	DIFF_CHANGED = "changed"
+)
+
+// DefaultCacheBusters are the query parameters ignored by DiffWith and Dedupe
+// when IgnoreCacheBusters is set and no CacheBusters are given.
+var DefaultCacheBusters = []string{"cb", "cachebuster", "cache_buster", "cachebust", "rnd", "rand", "random", "ord", "correlator", "ts", "timestamp", "_"}
+
+// Change is a difference between two documents reported by Diff.
+type Change struct {
+	// Element path, see EachURI. Removed elements have their path in the old
+	// document, added and changed elements their path in the new one.
+	// Attributes are appended as @name, e.g. Ad[0]@sequence.
+	Path string
+	// DIFF_ADDED, DIFF_REMOVED or DIFF_CHANGED
+	Kind string
+	// Value in the old and new document, empty for added and removed elements
+	// respectively
+	Old string
+	New string
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case DIFF_ADDED:
+		return fmt.Sprintf("+ %s %s", c.Path, c.New)
+	case DIFF_REMOVED:
+		return fmt.Sprintf("- %s %s", c.Path, c.Old)
+	}
+	return fmt.Sprintf("~ %s %s -> %s", c.Path, c.Old, c.New)
+}
+
+// DiffOptions tunes DiffWith.
+type DiffOptions struct {
+	// Ignore cache busting query parameters when comparing URIs
+	IgnoreCacheBusters bool
+	// Names of the cache busting query parameters, DefaultCacheBusters if
+	// empty. Parameters whose value is an unexpanded [CACHEBUSTING] or
+	// [TIMESTAMP] macro are always ignored.
+	CacheBusters []string
+}
+
+// Diff reports the ads, creatives, media files, trackers and extensions added,
+// removed or changed between the documents a and b, see DiffWith.
+func Diff(a, b *VAST) []Change {
+	return DiffWith(a, b, DiffOptions{})
+}
+
+// DiffWith reports the ads, creatives, media files, trackers and extensions
+// added, removed or changed between the documents a and b.
+//
+// Order is ignored where the spec does not make it significant: ads are
+// matched by id (or position when they have none), creatives by id or ad id,
+// companions and non linear creatives by id or size, icons by program,
+// extensions by type, and impressions, viewable impressions, error pixels,
+// trackers, clicks and media files are compared as sets.
+func DiffWith(a, b *VAST, opts DiffOptions) []Change {
+	if a == nil {
+		a = &VAST{}
+	}
+	if b == nil {
+		b = &VAST{}
+	}
+
+	d := &differ{uriKey: newURIKey(opts.IgnoreCacheBusters, opts.CacheBusters)}
+
+	d.value("", "", "@version", a.Version, b.Version)
+	d.cdatas("", "", "Error", a.Errors, b.Errors)
+	d.ads(a.Ads, b.Ads)
+	return d.changes
+}
+
+type differ struct {
+	uriKey
+	changes []Change
+}
+
+func (d *differ) add(path, kind, old, new string) {
+	d.changes = append(d.changes, Change{Path: path, Kind: kind, Old: old, New: new})
+}
+
+// value reports a changed scalar; name is an element or an @attribute
+func (d *differ) value(pa, pb, name, old, new string) {
+	if old == new {
+		return
+	}
+	path := pb + name
+	if !strings.HasPrefix(name, "@") {
+		path = joinPath(pb, name)
+	}
+	switch {
+	case old == "":
+		d.add(path, DIFF_ADDED, "", new)
+	case new == "":
+		if strings.HasPrefix(name, "@") {
+			path = pa + name
+		} else {
+			path = joinPath(pa, name)
+		}
+		d.add(path, DIFF_REMOVED, old, "")
+	default:
+		d.add(path, DIFF_CHANGED, old, new)
+	}
+}
+
+// uriKey computes the comparison form of URIs: trimmed and, if busters is not
+// nil, without cache busting parameters
+type uriKey struct {
+	busters map[string]bool
+}
+
+func newURIKey(ignoreCacheBusters bool, names []string) uriKey {
+	var k uriKey
+	if ignoreCacheBusters {
+		k.busters = map[string]bool{}
+		if len(names) == 0 {
+			names = DefaultCacheBusters
+		}
+		for _, name := range names {
+			k.busters[strings.ToLower(name)] = true
+		}
+	}
+	return k
+}
+
+func (k uriKey) uri(uri string) string {
+	uri = strings.TrimSpace(uri)
+	if k.busters == nil || !strings.Contains(uri, "?") {
+		return uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	q := u.Query()
+	for name, values := range q {
+		if k.busters[strings.ToLower(name)] || (len(values) == 1 && isCacheBusterMacro(values[0])) {
+			q.Del(name)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func isCacheBusterMacro(value string) bool {
+	switch strings.ToUpper(value) {
+	case "[CACHEBUSTING]", "[TIMESTAMP]", "%%CACHEBUSTER%%":
+		return true
+	}
+	return false
+}
+
+// item is an element compared by key; matched items whose value differ are
+// reported as changed, unmatched ones as added or removed with label.
+type item struct {
+	key   string
+	value string
+	label string
+}
+
+// matchKeys pairs the elements of a and b with equal keys, in order
+func matchKeys(a, b []string) (pairs [][2]int, removed, added []int) {
+	used := make([]bool, len(b))
+	for i, ka := range a {
+		found := false
+		for j, kb := range b {
+			if !used[j] && ka == kb {
+				used[j] = true
+				pairs = append(pairs, [2]int{i, j})
+				found = true
+				break
+			}
+		}
+		if !found {
+			removed = append(removed, i)
+		}
+	}
+	for j := range b {
+		if !used[j] {
+			added = append(added, j)
+		}
+	}
+	return pairs, removed, added
+}
+
+func itemKeys(items []item) []string {
+	keys := make([]string, len(items))
+	for i, it := range items {
+		keys[i] = it.key
+	}
+	return keys
+}
+
+// items diffs two sets of name elements under the parents pa and pb
+func (d *differ) items(pa, pb, name string, a, b []item) {
+	pairs, removed, added := matchKeys(itemKeys(a), itemKeys(b))
+	for _, i := range removed {
+		d.add(joinPath(pa, pathElem(name, i)), DIFF_REMOVED, a[i].label, "")
+	}
+	for _, j := range added {
+		d.add(joinPath(pb, pathElem(name, j)), DIFF_ADDED, "", b[j].label)
+	}
+	for _, p := range pairs {
+		if a[p[0]].value != b[p[1]].value {
+			d.add(joinPath(pb, pathElem(name, p[1])), DIFF_CHANGED, a[p[0]].value, b[p[1]].value)
+		}
+	}
+}
+
+func (d *differ) uriItems(uris []string) []item {
+	items := make([]item, len(uris))
+	for i, uri := range uris {
+		items[i] = item{key: d.uri(uri), label: uri}
+	}
+	return items
+}
+
+func (d *differ) cdatas(pa, pb, name string, a, b []CDATAString) {
+	d.items(pa, pb, name, d.uriItems(cdataURIs(a)), d.uriItems(cdataURIs(b)))
+}
+
+func cdataURIs(s []CDATAString) []string {
+	uris := make([]string, len(s))
+	for i, c := range s {
+		uris[i] = c.CDATA
+	}
+	return uris
+}
+
+func (d *differ) impressions(pa, pb string, a, b []Impression) {
+	uris := func(s []Impression) []string {
+		uris := make([]string, len(s))
+		for i, imp := range s {
+			uris[i] = imp.URI
+		}
+		return uris
+	}
+	d.items(pa, pb, "Impression", d.uriItems(uris(a)), d.uriItems(uris(b)))
+}
+
+func (d *differ) viewables(pa, pb string, a, b []Viewable) {
+	uris := func(s []Viewable) []string {
+		uris := make([]string, len(s))
+		for i, v := range s {
+			uris[i] = v.URI
+		}
+		return uris
+	}
+	pa, pb = joinPath(pa, "ViewableImpression"), joinPath(pb, "ViewableImpression")
+	d.items(pa, pb, "Viewable", d.uriItems(uris(a)), d.uriItems(uris(b)))
+}
+
+func (d *differ) clicks(pa, pb, name string, a, b []VideoClick) {
+	uris := func(s []VideoClick) []string {
+		uris := make([]string, len(s))
+		for i, c := range s {
+			uris[i] = c.URI
+		}
+		return uris
+	}
+	d.items(pa, pb, name, d.uriItems(uris(a)), d.uriItems(uris(b)))
+}
+
+func (d *differ) videoClicks(pa, pb string, a, b *VideoClicks) {
+	if a == nil {
+		a = &VideoClicks{}
+	}
+	if b == nil {
+		b = &VideoClicks{}
+	}
+	pa, pb = joinPath(pa, "VideoClicks"), joinPath(pb, "VideoClicks")
+	d.clicks(pa, pb, "ClickThrough", a.ClickThroughs, b.ClickThroughs)
+	d.clicks(pa, pb, "ClickTracking", a.ClickTrackings, b.ClickTrackings)
+	d.clicks(pa, pb, "CustomClick", a.CustomClicks, b.CustomClicks)
+}
+
+func (d *differ) trackings(pa, pb string, a, b []Tracking) {
+	items := func(s []Tracking) []item {
+		items := make([]item, len(s))
+		for i, t := range s {
+			label := t.Event
+			if t.Offset != nil {
+				label += "@" + textOf(t.Offset)
+			}
+			items[i] = item{key: label + " " + d.uri(t.URI), label: label + " " + t.URI}
+		}
+		return items
+	}
+	d.items(pa, pb, "Tracking", items(a), items(b))
+}
+
+func (d *differ) mediaFiles(pa, pb string, a, b []MediaFile) {
+	items := func(s []MediaFile) []item {
+		items := make([]item, len(s))
+		for i, m := range s {
+			items[i] = item{key: d.uri(m.URI), value: describeMediaFile(m), label: m.URI}
+		}
+		return items
+	}
+	d.items(pa, pb, "MediaFile", items(a), items(b))
+}
+
+func describeMediaFile(m MediaFile) string {
+	desc := fmt.Sprintf("%s %s %dx%d", m.Delivery, m.Type, m.Width, m.Height)
+	if m.Bitrate > 0 {
+		desc += " " + strconv.Itoa(m.Bitrate) + "kbps"
+	} else if m.MinBitrate > 0 || m.MaxBitrate > 0 {
+		desc += fmt.Sprintf(" %d-%dkbps", m.MinBitrate, m.MaxBitrate)
+	}
+	if m.APIFramework != "" {
+		desc += " " + m.APIFramework
+	}
+	return desc
+}
+
+func textOf(v interface{ MarshalText() ([]byte, error) }) string {
+	b, err := v.MarshalText()
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (d *differ) ads(a, b []Ad) {
+	key := func(ads []Ad) []string {
+		keys := make([]string, len(ads))
+		for i, ad := range ads {
+			keys[i] = ad.ID
+			if ad.ID == "" {
+				keys[i] = "#" + strconv.Itoa(i)
+			}
+		}
+		return keys
+	}
+	pairs, removed, added := matchKeys(key(a), key(b))
+	for _, i := range removed {
+		d.add(pathElem("Ad", i), DIFF_REMOVED, describeAd(&a[i]), "")
+	}
+	for _, j := range added {
+		d.add(pathElem("Ad", j), DIFF_ADDED, "", describeAd(&b[j]))
+	}
+	for _, p := range pairs {
+		d.ad(pathElem("Ad", p[0]), pathElem("Ad", p[1]), &a[p[0]], &b[p[1]])
+	}
+}
+
+func describeAd(ad *Ad) string {
+	kind := "empty"
+	if ad.InLine != nil {
+		kind = "InLine"
+	} else if ad.Wrapper != nil {
+		kind = "Wrapper"
+	}
+	if ad.ID == "" {
+		return kind
+	}
+	return kind + " " + ad.ID
+}
+
+func describeAdSystem(s *AdSystem) string {
+	if s == nil {
+		return ""
+	}
+	if s.Version == "" {
+		return s.Name
+	}
+	return s.Name + " " + s.Version
+}
+
+func (d *differ) ad(pa, pb string, a, b *Ad) {
+	if a.Sequence != b.Sequence {
+		d.add(pb+"@sequence", DIFF_CHANGED, strconv.Itoa(a.Sequence), strconv.Itoa(b.Sequence))
+	}
+
+	switch {
+	case a.InLine != nil && b.InLine != nil:
+		pa, pb := joinPath(pa, "InLine"), joinPath(pb, "InLine")
+		ia, ib := a.InLine, b.InLine
+		d.value(pa, pb, "AdSystem", describeAdSystem(ia.AdSystem), describeAdSystem(ib.AdSystem))
+		d.value(pa, pb, "AdTitle", ia.AdTitle.CDATA, ib.AdTitle.CDATA)
+		d.value(pa, pb, "Advertiser", ia.Advertiser, ib.Advertiser)
+		d.value(pa, pb, "Pricing", ia.Pricing, ib.Pricing)
+		d.impressions(pa, pb, ia.Impressions, ib.Impressions)
+		d.viewables(pa, pb, ia.ViewableImpression, ib.ViewableImpression)
+		d.cdatas(pa, pb, "Error", ia.Errors, ib.Errors)
+		d.creatives(joinPath(pa, "Creatives"), joinPath(pb, "Creatives"), ia.Creatives, ib.Creatives)
+		d.extensions(joinPath(pa, "Extensions"), joinPath(pb, "Extensions"), ia.Extensions, ib.Extensions)
+	case a.Wrapper != nil && b.Wrapper != nil:
+		pa, pb := joinPath(pa, "Wrapper"), joinPath(pb, "Wrapper")
+		wa, wb := a.Wrapper, b.Wrapper
+		d.value(pa, pb, "AdSystem", describeAdSystem(wa.AdSystem), describeAdSystem(wb.AdSystem))
+		if d.uri(wa.VASTAdTagURI.CDATA) != d.uri(wb.VASTAdTagURI.CDATA) {
+			d.value(pa, pb, "VASTAdTagURI", wa.VASTAdTagURI.CDATA, wb.VASTAdTagURI.CDATA)
+		}
+		d.impressions(pa, pb, wa.Impressions, wb.Impressions)
+		d.viewables(pa, pb, wa.ViewableImpression, wb.ViewableImpression)
+		d.cdatas(pa, pb, "Error", wa.Errors, wb.Errors)
+		d.creativeWrappers(joinPath(pa, "Creatives"), joinPath(pb, "Creatives"), wa.Creatives, wb.Creatives)
+		d.extensions(joinPath(pa, "Extensions"), joinPath(pb, "Extensions"), wa.Extensions, wb.Extensions)
+	default:
+		if oa, ob := describeAd(a), describeAd(b); oa != ob {
+			d.add(pb, DIFF_CHANGED, oa, ob)
+		}
+	}
+}
+
+// creativeKeys matches creatives by id, ad id or position
+func creativeKeys(n int, ids func(i int) (string, string)) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		id, adID := ids(i)
+		switch {
+		case id != "":
+			keys[i] = "id:" + id
+		case adID != "":
+			keys[i] = "ad:" + adID
+		default:
+			keys[i] = "#" + strconv.Itoa(i)
+		}
+	}
+	return keys
+}
+
+func describeCreative(linear, nonlinear, companions bool) string {
+	var kinds []string
+	if linear {
+		kinds = append(kinds, "Linear")
+	}
+	if nonlinear {
+		kinds = append(kinds, "NonLinearAds")
+	}
+	if companions {
+		kinds = append(kinds, "CompanionAds")
+	}
+	return strings.Join(kinds, " ")
+}
+
+// part reports a creative part present in only one document, returning
+// whether it is present in both
+func (d *differ) part(pa, pb, name string, inA, inB bool) bool {
+	if inA && !inB {
+		d.add(joinPath(pa, name), DIFF_REMOVED, name, "")
+	} else if inB && !inA {
+		d.add(joinPath(pb, name), DIFF_ADDED, "", name)
+	}
+	return inA && inB
+}
+
+func (d *differ) creatives(pa, pb string, a, b []Creative) {
+	pairs, removed, added := matchKeys(
+		creativeKeys(len(a), func(i int) (string, string) { return a[i].ID, a[i].AdID }),
+		creativeKeys(len(b), func(i int) (string, string) { return b[i].ID, b[i].AdID }),
+	)
+	for _, i := range removed {
+		c := &a[i]
+		d.add(joinPath(pa, pathElem("Creative", i)), DIFF_REMOVED, describeCreative(c.Linear != nil, c.NonLinearAds != nil, c.CompanionAds != nil), "")
+	}
+	for _, j := range added {
+		c := &b[j]
+		d.add(joinPath(pb, pathElem("Creative", j)), DIFF_ADDED, "", describeCreative(c.Linear != nil, c.NonLinearAds != nil, c.CompanionAds != nil))
+	}
+	for _, p := range pairs {
+		ca, cb := &a[p[0]], &b[p[1]]
+		pa, pb := joinPath(pa, pathElem("Creative", p[0])), joinPath(pb, pathElem("Creative", p[1]))
+		d.value(pa, pb, "@sequence", sequenceText(ca.Sequence), sequenceText(cb.Sequence))
+
+		if d.part(pa, pb, "Linear", ca.Linear != nil, cb.Linear != nil) {
+			la, lb := ca.Linear, cb.Linear
+			pa, pb := joinPath(pa, "Linear"), joinPath(pb, "Linear")
+			d.value(pa, pb, "Duration", textOf(la.Duration), textOf(lb.Duration))
+			d.value(pa, pb, "@skipoffset", offsetText(la.SkipOffset), offsetText(lb.SkipOffset))
+			d.trackings(joinPath(pa, "TrackingEvents"), joinPath(pb, "TrackingEvents"), la.TrackingEvents, lb.TrackingEvents)
+			d.videoClicks(pa, pb, la.VideoClicks, lb.VideoClicks)
+			d.mediaFiles(joinPath(pa, "MediaFiles"), joinPath(pb, "MediaFiles"), la.MediaFiles, lb.MediaFiles)
+			d.icons(joinPath(pa, "Icons"), joinPath(pb, "Icons"), la.Icons, lb.Icons)
+		}
+		if d.part(pa, pb, "NonLinearAds", ca.NonLinearAds != nil, cb.NonLinearAds != nil) {
+			pa, pb := joinPath(pa, "NonLinearAds"), joinPath(pb, "NonLinearAds")
+			d.trackings(joinPath(pa, "TrackingEvents"), joinPath(pb, "TrackingEvents"), ca.NonLinearAds.TrackingEvents, cb.NonLinearAds.TrackingEvents)
+			d.elements(pa, pb, "NonLinear", "NonLinearClickTracking", nonLinearViews(ca.NonLinearAds.NonLinears), nonLinearViews(cb.NonLinearAds.NonLinears))
+		}
+		if d.part(pa, pb, "CompanionAds", ca.CompanionAds != nil, cb.CompanionAds != nil) {
+			d.elements(joinPath(pa, "CompanionAds"), joinPath(pb, "CompanionAds"), "Companion", "CompanionClickTracking", companionViews(ca.CompanionAds.Companions), companionViews(cb.CompanionAds.Companions))
+		}
+	}
+}
+
+func (d *differ) creativeWrappers(pa, pb string, a, b []CreativeWrapper) {
+	pairs, removed, added := matchKeys(
+		creativeKeys(len(a), func(i int) (string, string) { return a[i].ID, a[i].AdID }),
+		creativeKeys(len(b), func(i int) (string, string) { return b[i].ID, b[i].AdID }),
+	)
+	for _, i := range removed {
+		c := &a[i]
+		d.add(joinPath(pa, pathElem("Creative", i)), DIFF_REMOVED, describeCreative(c.Linear != nil, c.NonLinearAds != nil, c.CompanionAds != nil), "")
+	}
+	for _, j := range added {
+		c := &b[j]
+		d.add(joinPath(pb, pathElem("Creative", j)), DIFF_ADDED, "", describeCreative(c.Linear != nil, c.NonLinearAds != nil, c.CompanionAds != nil))
+	}
+	for _, p := range pairs {
+		ca, cb := &a[p[0]], &b[p[1]]
+		pa, pb := joinPath(pa, pathElem("Creative", p[0])), joinPath(pb, pathElem("Creative", p[1]))
+		d.value(pa, pb, "@sequence", sequenceText(ca.Sequence), sequenceText(cb.Sequence))
+
+		if d.part(pa, pb, "Linear", ca.Linear != nil, cb.Linear != nil) {
+			pa, pb := joinPath(pa, "Linear"), joinPath(pb, "Linear")
+			d.trackings(joinPath(pa, "TrackingEvents"), joinPath(pb, "TrackingEvents"), ca.Linear.TrackingEvents, cb.Linear.TrackingEvents)
+			d.videoClicks(pa, pb, ca.Linear.VideoClicks, cb.Linear.VideoClicks)
+			d.icons(joinPath(pa, "Icons"), joinPath(pb, "Icons"), ca.Linear.Icons, cb.Linear.Icons)
+		}
+		if d.part(pa, pb, "NonLinearAds", ca.NonLinearAds != nil, cb.NonLinearAds != nil) {
+			pa, pb := joinPath(pa, "NonLinearAds"), joinPath(pb, "NonLinearAds")
+			d.trackings(joinPath(pa, "TrackingEvents"), joinPath(pb, "TrackingEvents"), ca.NonLinearAds.TrackingEvents, cb.NonLinearAds.TrackingEvents)
+			d.elements(pa, pb, "NonLinear", "NonLinearClickTracking", nonLinearWrapperViews(ca.NonLinearAds.NonLinears), nonLinearWrapperViews(cb.NonLinearAds.NonLinears))
+		}
+		if d.part(pa, pb, "CompanionAds", ca.CompanionAds != nil, cb.CompanionAds != nil) {
+			d.elements(joinPath(pa, "CompanionAds"), joinPath(pb, "CompanionAds"), "Companion", "CompanionClickTracking", companionWrapperViews(ca.CompanionAds.Companions), companionWrapperViews(cb.CompanionAds.Companions))
+		}
+	}
+}
+
+func sequenceText(seq int) string {
+	if seq == 0 {
+		return ""
+	}
+	return strconv.Itoa(seq)
+}
+
+func offsetText(o *Offset) string {
+	if o == nil {
+		return ""
+	}
+	return textOf(o)
+}
+
+// elementView holds the compared fields of companions and non linear
+// creatives: their trackers and click trackers
+type elementView struct {
+	key       string
+	trackings []Tracking
+	clicks    []CDATAString
+}
+
+// elementKey matches companions and non linear creatives by id or size
+func elementKey(id string, w, h int) string {
+	if id != "" {
+		return id
+	}
+	return fmt.Sprintf("%dx%d", w, h)
+}
+
+func companionViews(s []Companion) []elementView {
+	views := make([]elementView, len(s))
+	for i, c := range s {
+		views[i] = elementView{elementKey(c.ID, c.Width, c.Height), c.TrackingEvents, c.CompanionClickTracking}
+	}
+	return views
+}
+
+func companionWrapperViews(s []CompanionWrapper) []elementView {
+	views := make([]elementView, len(s))
+	for i, c := range s {
+		views[i] = elementView{elementKey(c.ID, c.Width, c.Height), c.TrackingEvents, c.CompanionClickTracking}
+	}
+	return views
+}
+
+func nonLinearViews(s []NonLinear) []elementView {
+	views := make([]elementView, len(s))
+	for i, n := range s {
+		views[i] = elementView{elementKey(n.ID, n.Width, n.Height), nil, n.NonLinearClickTracking}
+	}
+	return views
+}
+
+func nonLinearWrapperViews(s []NonLinearWrapper) []elementView {
+	views := make([]elementView, len(s))
+	for i, n := range s {
+		views[i] = elementView{elementKey(n.ID, n.Width, n.Height), n.TrackingEvents, n.NonLinearClickTracking}
+	}
+	return views
+}
+
+// elements diffs the name elements, e.g. Companion, under pa and pb and their
+// trackers and click trackers
+func (d *differ) elements(pa, pb, name, clickName string, a, b []elementView) {
+	keys := func(views []elementView) []string {
+		keys := make([]string, len(views))
+		for i, v := range views {
+			keys[i] = v.key
+		}
+		return keys
+	}
+	pairs, removed, added := matchKeys(keys(a), keys(b))
+	for _, i := range removed {
+		d.add(joinPath(pa, pathElem(name, i)), DIFF_REMOVED, a[i].key, "")
+	}
+	for _, j := range added {
+		d.add(joinPath(pb, pathElem(name, j)), DIFF_ADDED, "", b[j].key)
+	}
+	for _, p := range pairs {
+		pa, pb := joinPath(pa, pathElem(name, p[0])), joinPath(pb, pathElem(name, p[1]))
+		d.trackings(joinPath(pa, "TrackingEvents"), joinPath(pb, "TrackingEvents"), a[p[0]].trackings, b[p[1]].trackings)
+		d.cdatas(pa, pb, clickName, a[p[0]].clicks, b[p[1]].clicks)
+	}
+}
+
+// icons matches icons by program or position and diffs their click and view
+// trackers
+func (d *differ) icons(pa, pb string, a, b *Icons) {
+	if a == nil {
+		a = &Icons{}
+	}
+	if b == nil {
+		b = &Icons{}
+	}
+	keys := func(icons []Icon) []string {
+		keys := make([]string, len(icons))
+		for i, icon := range icons {
+			keys[i] = icon.Program
+			if icon.Program == "" {
+				keys[i] = "#" + strconv.Itoa(i)
+			}
+		}
+		return keys
+	}
+	pairs, removed, added := matchKeys(keys(a.Icon), keys(b.Icon))
+	for _, i := range removed {
+		d.add(joinPath(pa, pathElem("Icon", i)), DIFF_REMOVED, a.Icon[i].Program, "")
+	}
+	for _, j := range added {
+		d.add(joinPath(pb, pathElem("Icon", j)), DIFF_ADDED, "", b.Icon[j].Program)
+	}
+	for _, p := range pairs {
+		ia, ib := &a.Icon[p[0]], &b.Icon[p[1]]
+		pa, pb := joinPath(pa, pathElem("Icon", p[0])), joinPath(pb, pathElem("Icon", p[1]))
+		d.cdatas(joinPath(pa, "IconClicks"), joinPath(pb, "IconClicks"), "IconClickTracking", ia.IconClickTrackings, ib.IconClickTrackings)
+		d.cdatas(pa, pb, "IconViewTracking", ia.IconViewTrackings, ib.IconViewTrackings)
+	}
+}
+
+func (d *differ) extensions(pa, pb string, a, b []Extension) {
+	keys := func(exts []Extension) []string {
+		keys := make([]string, len(exts))
+		for i, e := range exts {
+			keys[i] = e.Type
+		}
+		return keys
+	}
+	pairs, removed, added := matchKeys(keys(a), keys(b))
+	for _, i := range removed {
+		d.add(joinPath(pa, pathElem("Extension", i)), DIFF_REMOVED, a[i].Type, "")
+	}
+	for _, j := range added {
+		d.add(joinPath(pb, pathElem("Extension", j)), DIFF_ADDED, "", b[j].Type)
+	}
+	for _, p := range pairs {
+		ea, eb := &a[p[0]], &b[p[1]]
+		pa, pb := joinPath(pa, pathElem("Extension", p[0])), joinPath(pb, pathElem("Extension", p[1]))
+		if da, db := bytes.TrimSpace(ea.Data), bytes.TrimSpace(eb.Data); !bytes.Equal(da, db) {
+			d.add(pb, DIFF_CHANGED, string(da), string(db))
+		}
+		d.trackings(joinPath(pa, "CustomTracking"), joinPath(pb, "CustomTracking"), ea.CustomTracking, eb.CustomTracking)
+	}
+}
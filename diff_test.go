@@ -0,0 +1,121 @@
+package vast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSame(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_inline_linear.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, Diff(v, v.Clone()))
+
+	l := v.Ads[0].InLine.Creatives[0].Linear
+	l.MediaFiles = append(l.MediaFiles, MediaFile{Delivery: "progressive", Type: "video/mp4", Width: 640, Height: 360, URI: "http://us.com/ad.mp4"})
+
+	// order of trackers and media files is not significant
+	c := v.Clone()
+	l = c.Ads[0].InLine.Creatives[0].Linear
+	l.TrackingEvents[0], l.TrackingEvents[1] = l.TrackingEvents[1], l.TrackingEvents[0]
+	l.MediaFiles[0], l.MediaFiles[1] = l.MediaFiles[1], l.MediaFiles[0]
+	assert.Empty(t, Diff(v, c))
+}
+
+func TestDiff(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_inline_linear.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c := v.Clone()
+	inline := c.Ads[0].InLine
+	inline.AdTitle.CDATA = "New title"
+	inline.Impressions = append(inline.Impressions, Impression{URI: "http://us.com/imp"})
+	l := inline.Creatives[0].Linear
+	removed := l.TrackingEvents[0]
+	l.TrackingEvents = l.TrackingEvents[1:]
+	l.MediaFiles[0].Width = 1920
+	inline.Creatives = inline.Creatives[:1]
+
+	assert.Equal(t, []Change{
+		{Path: "Ad[0]/InLine/AdTitle", Kind: DIFF_CHANGED, Old: "VAST 2.0 Instream Test 1", New: "New title"},
+		{Path: "Ad[0]/InLine/Impression[2]", Kind: DIFF_ADDED, New: "http://us.com/imp"},
+		{Path: "Ad[0]/InLine/Creatives/Creative[1]", Kind: DIFF_REMOVED, Old: "CompanionAds"},
+		{Path: "Ad[0]/InLine/Creatives/Creative[0]/Linear/TrackingEvents/Tracking[0]", Kind: DIFF_REMOVED, Old: removed.Event + " " + removed.URI},
+		{Path: "Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[0]", Kind: DIFF_CHANGED,
+			Old: "progressive video/x-flv 400x300 500kbps", New: "progressive video/x-flv 1920x300 500kbps"},
+	}, Diff(v, c))
+
+	w, _, _, err := loadFixture("testdata/vast_wrapper_linear_1.xml")
+	if assert.NoError(t, err) {
+		changes := Diff(v, w)
+		if assert.Len(t, changes, 2) {
+			assert.Equal(t, Change{Path: "Ad[0]", Kind: DIFF_REMOVED, Old: "InLine 601364"}, changes[0])
+			assert.Equal(t, DIFF_ADDED, changes[1].Kind)
+		}
+	}
+}
+
+func TestDiffPixels(t *testing.T) {
+	doc := func(viewable, iconClick, iconView, nonLinearClick string) *VAST {
+		return &VAST{Ads: []Ad{{ID: "1", InLine: &InLine{
+			ViewableImpression: []Viewable{{URI: viewable}},
+			Creatives: []Creative{
+				{ID: "l", Linear: &Linear{Icons: &Icons{Icon: []Icon{{
+					Program:            "AdChoices",
+					IconClickTrackings: []CDATAString{{iconClick}},
+					IconViewTrackings:  []CDATAString{{iconView}},
+				}}}}},
+				{ID: "n", NonLinearAds: &NonLinearAds{NonLinears: []NonLinear{{
+					ID:                     "nl",
+					NonLinearClickTracking: []CDATAString{{nonLinearClick}},
+				}}}},
+			},
+		}}}}
+	}
+	a := doc("http://view", "http://icon/click", "http://icon/view", "http://nl/click")
+	assert.Empty(t, Diff(a, a.Clone()))
+
+	b := doc("http://view2", "http://icon/click2", "http://icon/view2", "http://nl/click2")
+	var paths []string
+	for _, c := range Diff(a, b) {
+		if c.Kind == DIFF_ADDED {
+			paths = append(paths, c.Path)
+		}
+	}
+	assert.Equal(t, []string{
+		"Ad[0]/InLine/ViewableImpression/Viewable[0]",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/Icons/Icon[0]/IconClicks/IconClickTracking[0]",
+		"Ad[0]/InLine/Creatives/Creative[0]/Linear/Icons/Icon[0]/IconViewTracking[0]",
+		"Ad[0]/InLine/Creatives/Creative[1]/NonLinearAds/NonLinear[0]/NonLinearClickTracking[0]",
+	}, paths)
+
+	// the paths are those of EachURI
+	var uris []string
+	b.EachURI(func(path string, _ *string) {
+		uris = append(uris, path)
+	})
+	for _, path := range paths {
+		assert.Contains(t, uris, path)
+	}
+}
+
+func TestDiffCacheBusters(t *testing.T) {
+	a := &VAST{Ads: []Ad{{ID: "1", Wrapper: &Wrapper{
+		VASTAdTagURI: CDATAString{"https://up.com/vast?id=1&cb=123"},
+		Impressions:  []Impression{{URI: "https://us.com/imp?ord=111&x=1"}},
+	}}}}
+	b := &VAST{Ads: []Ad{{ID: "1", Wrapper: &Wrapper{
+		VASTAdTagURI: CDATAString{"https://up.com/vast?cb=456&id=1"},
+		Impressions:  []Impression{{URI: "https://us.com/imp?x=1&ord=[CACHEBUSTING]"}},
+	}}}}
+
+	assert.Len(t, Diff(a, b), 3)
+	assert.Empty(t, DiffWith(a, b, DiffOptions{IgnoreCacheBusters: true}))
+	assert.Len(t, DiffWith(a, b, DiffOptions{IgnoreCacheBusters: true, CacheBusters: []string{"ord"}}), 1)
+
+	assert.Equal(t, "~ Ad[0]/Wrapper/VASTAdTagURI https://up.com/vast?id=1&cb=123 -> https://up.com/vast?cb=456&id=1", Diff(a, b)[0].String())
+}
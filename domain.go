@@ -0,0 +1,320 @@
+package vast
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Actions of a DomainPolicy
+const (
+	DOMAIN_DROP_URI      = "drop-uri"
+	DOMAIN_DROP_CREATIVE = "drop-creative"
+	DOMAIN_REJECT_AD     = "reject-ad"
+)
+
+// DomainPolicy restricts the hosts the URIs of a document may point to.
+// Patterns are host names, matching that host only, or wildcards such as
+// *.example.com, matching example.com itself and every subdomain of it.
+type DomainPolicy struct {
+	// If not empty, only hosts matching one of these patterns are allowed
+	Allow []string
+	// Hosts matching one of these patterns are not allowed
+	Block []string
+	// What to do with a URI which is not allowed: DOMAIN_DROP_URI (default),
+	// DOMAIN_DROP_CREATIVE or DOMAIN_REJECT_AD
+	Mode string
+}
+
+// DomainViolation is a URI rejected by a DomainPolicy.
+type DomainViolation struct {
+	// Element path of the URI before the policy was applied, see EachURI
+	Path string
+	URI  string
+	Host string
+	// Action taken, one of the DOMAIN_ actions
+	Action string
+}
+
+// Allowed reports whether the policy allows host. An empty host, of a URI
+// whose host can't be found, is only allowed by an empty policy.
+func (p DomainPolicy) Allowed(host string) bool {
+	host = strings.ToLower(host)
+	if host == "" {
+		return len(p.Allow) == 0 && len(p.Block) == 0
+	}
+	if matchHostPatterns(host, p.Block) {
+		return false
+	}
+	return len(p.Allow) == 0 || matchHostPatterns(host, p.Allow)
+}
+
+func matchHostPatterns(host string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if strings.HasPrefix(pattern, "*.") {
+			if strings.HasSuffix(host, pattern[1:]) || host == pattern[2:] {
+				return true
+			}
+		} else if pattern != "" && host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// uriHost returns the lower case host of an absolute or protocol relative
+// URI, empty for others. URIs which do not parse, e.g. with a bad escape or an
+// unexpanded [PROTOCOL] macro as scheme, are read leniently: the host is the
+// authority following the first //.
+func uriHost(uri string) string {
+	uri = strings.TrimSpace(uri)
+	if u, err := url.Parse(uri); err == nil {
+		return strings.ToLower(u.Hostname())
+	}
+
+	i := strings.Index(uri, "//")
+	if i < 0 || strings.ContainsAny(uri[:i], "/?#") {
+		return ""
+	}
+	authority := uri[i+2:]
+	if j := strings.IndexAny(authority, "/?#"); j >= 0 {
+		authority = authority[:j]
+	}
+	if j := strings.LastIndexByte(authority, '@'); j >= 0 {
+		authority = authority[j+1:]
+	}
+	if strings.HasPrefix(authority, "[") {
+		if j := strings.IndexByte(authority, ']'); j >= 0 {
+			return strings.ToLower(authority[1:j])
+		}
+		return ""
+	}
+	if j := strings.IndexByte(authority, ':'); j >= 0 {
+		authority = authority[:j]
+	}
+	return strings.ToLower(authority)
+}
+
+var creativePath = regexp.MustCompile(`^Ad\[(\d+)\]/(?:InLine|Wrapper)(?:/Creatives/Creative\[(\d+)\])?`)
+
+// ApplyDomainPolicy checks every URI of the document (see EachURI) against the
+// policy. URIs which are not allowed are removed, along with their creative or
+// their ad depending on the policy mode. URIs outside of a creative (pixels of
+// the ad or document) are removed when the mode is DOMAIN_DROP_CREATIVE, and a
+// disallowed wrapped tag URI always rejects its ad. Remaining pod ads are
+// renumbered from 1. URIs whose host can't be found are disallowed unless the
+// policy is empty. It returns the disallowed URIs and the action taken.
+func (v *VAST) ApplyDomainPolicy(p DomainPolicy) []DomainViolation {
+	var violations []DomainViolation
+	var drop []*string
+	rejected := map[int]bool{}
+	dropped := map[[2]int]bool{}
+
+	v.EachURI(func(path string, uri *string) {
+		host := uriHost(*uri)
+		if p.Allowed(host) {
+			return
+		}
+
+		ad, creative := -1, -1
+		if m := creativePath.FindStringSubmatch(path); m != nil {
+			ad, _ = strconv.Atoi(m[1])
+			if m[2] != "" {
+				creative, _ = strconv.Atoi(m[2])
+			}
+		}
+
+		action := DOMAIN_DROP_URI
+		switch {
+		case ad >= 0 && (p.Mode == DOMAIN_REJECT_AD || strings.HasSuffix(path, "/VASTAdTagURI")):
+			action = DOMAIN_REJECT_AD
+			rejected[ad] = true
+		case creative >= 0 && p.Mode == DOMAIN_DROP_CREATIVE:
+			action = DOMAIN_DROP_CREATIVE
+			dropped[[2]int{ad, creative}] = true
+		default:
+			drop = append(drop, uri)
+		}
+		violations = append(violations, DomainViolation{Path: path, URI: *uri, Host: host, Action: action})
+	})
+	if len(violations) == 0 {
+		return nil
+	}
+
+	for _, uri := range drop {
+		*uri = ""
+	}
+	ads := v.Ads[:0]
+	for i, ad := range v.Ads {
+		if rejected[i] {
+			continue
+		}
+		if ad.InLine != nil {
+			creatives := ad.InLine.Creatives[:0]
+			for j, c := range ad.InLine.Creatives {
+				if !dropped[[2]int{i, j}] {
+					creatives = append(creatives, c)
+				}
+			}
+			ad.InLine.Creatives = creatives
+		} else if ad.Wrapper != nil {
+			creatives := ad.Wrapper.Creatives[:0]
+			for j, c := range ad.Wrapper.Creatives {
+				if !dropped[[2]int{i, j}] {
+					creatives = append(creatives, c)
+				}
+			}
+			ad.Wrapper.Creatives = creatives
+		}
+		ads = append(ads, ad)
+	}
+	v.Ads = ads
+	v.dropEmptyURIs()
+	v.renumberPod()
+	return violations
+}
+
+// ThirdPartyDomains returns the sorted hosts contacted by the ad, see EachURI,
+// except those matching the first party patterns (see DomainPolicy).
+func (ad *Ad) ThirdPartyDomains(firstParty ...string) []string {
+	seen := map[string]bool{}
+	var hosts []string
+	ad.EachURI(func(_ string, uri *string) {
+		host := uriHost(*uri)
+		if host != "" && !seen[host] && !matchHostPatterns(host, firstParty) {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	})
+	sort.Strings(hosts)
+	return hosts
+}
+
+// dropEmptyURIs removes the repeatable URI elements left empty
+func (v *VAST) dropEmptyURIs() {
+	v.Errors = filterCDATAs(v.Errors)
+	for _, ad := range v.Ads {
+		if inline := ad.InLine; inline != nil {
+			inline.Impressions = filterImpressions(inline.Impressions)
+			inline.ViewableImpression = filterViewables(inline.ViewableImpression)
+			inline.Errors = filterCDATAs(inline.Errors)
+			for _, c := range inline.Creatives {
+				if l := c.Linear; l != nil {
+					l.TrackingEvents = filterTrackings(l.TrackingEvents)
+					filterVideoClicks(l.VideoClicks)
+					filterIcons(l.Icons)
+					media := l.MediaFiles[:0]
+					for _, m := range l.MediaFiles {
+						if m.URI != "" {
+							media = append(media, m)
+						}
+					}
+					l.MediaFiles = media
+				}
+				if n := c.NonLinearAds; n != nil {
+					n.TrackingEvents = filterTrackings(n.TrackingEvents)
+					for i := range n.NonLinears {
+						n.NonLinears[i].NonLinearClickTracking = filterCDATAs(n.NonLinears[i].NonLinearClickTracking)
+					}
+				}
+				if ca := c.CompanionAds; ca != nil {
+					for i := range ca.Companions {
+						comp := &ca.Companions[i]
+						comp.CompanionClickTracking = filterCDATAs(comp.CompanionClickTracking)
+						comp.TrackingEvents = filterTrackings(comp.TrackingEvents)
+					}
+				}
+			}
+			for i := range inline.Extensions {
+				inline.Extensions[i].CustomTracking = filterTrackings(inline.Extensions[i].CustomTracking)
+			}
+		} else if wrap := ad.Wrapper; wrap != nil {
+			wrap.Impressions = filterImpressions(wrap.Impressions)
+			wrap.ViewableImpression = filterViewables(wrap.ViewableImpression)
+			wrap.Errors = filterCDATAs(wrap.Errors)
+			for _, c := range wrap.Creatives {
+				if l := c.Linear; l != nil {
+					l.TrackingEvents = filterTrackings(l.TrackingEvents)
+					filterVideoClicks(l.VideoClicks)
+					filterIcons(l.Icons)
+				}
+				if n := c.NonLinearAds; n != nil {
+					n.TrackingEvents = filterTrackings(n.TrackingEvents)
+					for i := range n.NonLinears {
+						nl := &n.NonLinears[i]
+						nl.TrackingEvents = filterTrackings(nl.TrackingEvents)
+						nl.NonLinearClickTracking = filterCDATAs(nl.NonLinearClickTracking)
+					}
+				}
+				if ca := c.CompanionAds; ca != nil {
+					for i := range ca.Companions {
+						comp := &ca.Companions[i]
+						comp.CompanionClickTracking = filterCDATAs(comp.CompanionClickTracking)
+						comp.TrackingEvents = filterTrackings(comp.TrackingEvents)
+					}
+				}
+			}
+			for i := range wrap.Extensions {
+				wrap.Extensions[i].CustomTracking = filterTrackings(wrap.Extensions[i].CustomTracking)
+			}
+		}
+	}
+}
+
+func filterCDATAs(s []CDATAString) []CDATAString {
+	f := s[:0]
+	for _, c := range s {
+		if c.CDATA != "" {
+			f = append(f, c)
+		}
+	}
+	return f
+}
+
+func filterImpressions(s []Impression) []Impression {
+	f := s[:0]
+	for _, imp := range s {
+		if imp.URI != "" {
+			f = append(f, imp)
+		}
+	}
+	return f
+}
+
+func filterViewables(s []Viewable) []Viewable {
+	f := s[:0]
+	for _, imp := range s {
+		if imp.URI != "" {
+			f = append(f, imp)
+		}
+	}
+	return f
+}
+
+func filterTrackings(s []Tracking) []Tracking {
+	f := s[:0]
+	for _, t := range s {
+		if t.URI != "" {
+			f = append(f, t)
+		}
+	}
+	return f
+}
+
+func filterVideoClicks(clicks *VideoClicks) {
+	if clicks != nil {
+		clicks.Validate()
+	}
+}
+
+func filterIcons(icons *Icons) {
+	if icons != nil {
+		for i := range icons.Icon {
+			icons.Icon[i].IconClickTrackings = filterCDATAs(icons.Icon[i].IconClickTrackings)
+			icons.Icon[i].IconViewTrackings = filterCDATAs(icons.Icon[i].IconViewTrackings)
+		}
+	}
+}
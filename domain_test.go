@@ -0,0 +1,136 @@
+package vast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainPolicyAllowed(t *testing.T) {
+	p := DomainPolicy{Block: []string{"bad.com", "*.evil.net"}}
+	assert.False(t, p.Allowed("bad.com"))
+	assert.True(t, p.Allowed("cdn.bad.com"))
+	assert.False(t, p.Allowed("x.EVIL.net"))
+	// wildcards match the apex domain too
+	assert.False(t, p.Allowed("evil.net"))
+	assert.True(t, p.Allowed("notevil.net"))
+
+	p = DomainPolicy{Allow: []string{"*.us.com"}, Block: []string{"spy.us.com"}}
+	assert.True(t, p.Allowed("us.com"))
+	assert.True(t, p.Allowed("cdn.us.com"))
+	assert.False(t, p.Allowed("spy.us.com"))
+	assert.False(t, p.Allowed("them.com"))
+
+	// unknown hosts
+	assert.False(t, p.Allowed(""))
+	assert.False(t, DomainPolicy{Block: []string{"bad.com"}}.Allowed(""))
+	assert.True(t, DomainPolicy{}.Allowed(""))
+}
+
+func TestURIHost(t *testing.T) {
+	for uri, host := range map[string]string{
+		" https://Ads.Example.com:8443/p?x=1 ": "ads.example.com",
+		"//cdn.example.com/a.mp4":              "cdn.example.com",
+		"https://blocked.com/p%zz":             "blocked.com",
+		"[PROTOCOL]://blocked.com/p":           "blocked.com",
+		"https://user@blocked.com:80?q=%zz":    "blocked.com",
+		"http://[::1]:8080/%zz":                "::1",
+		"/relative/path":                       "",
+		"/redirect?u=http://x.com/%zz":         "",
+		"":                                     "",
+	} {
+		assert.Equal(t, host, uriHost(uri), uri)
+	}
+}
+
+func TestApplyDomainPolicyMalformed(t *testing.T) {
+	v := &VAST{Ads: []Ad{{InLine: &InLine{Impressions: []Impression{
+		{URI: "https://blocked.com/p%zz"},
+		{URI: "[PROTOCOL]://blocked.com/p"},
+		{URI: "not a uri %zz"},
+		{URI: "https://good.com/p"},
+	}}}}}
+	violations := v.ApplyDomainPolicy(DomainPolicy{Block: []string{"blocked.com"}})
+	if assert.Len(t, violations, 3) {
+		assert.Equal(t, "blocked.com", violations[0].Host)
+		assert.Equal(t, "blocked.com", violations[1].Host)
+		assert.Equal(t, "", violations[2].Host)
+	}
+	assert.Equal(t, []Impression{{URI: "https://good.com/p"}}, v.Ads[0].InLine.Impressions)
+}
+
+func loadPolicyFixture(t *testing.T) *VAST {
+	v, _, _, err := loadFixture("testdata/vast_inline_linear.xml")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	v.Ads = append(v.Ads, *v.Ads[0].Clone())
+	v.Ads[1].ID = "second"
+	v.Ads[1].InLine.Creatives[0].Linear.MediaFiles[0].URI = "http://cdn.example.com/ad.mp4"
+	v.Errors = []CDATAString{{"http://myErrorURL/no-ad"}}
+	return v
+}
+
+func TestApplyDomainPolicyDropURI(t *testing.T) {
+	v := loadPolicyFixture(t)
+
+	violations := v.ApplyDomainPolicy(DomainPolicy{Block: []string{"mytrackingurl", "myerrorurl"}})
+	assert.Len(t, violations, 2*(2+2+6+1+1)+1)
+	assert.Equal(t, DomainViolation{Path: "Error[0]", URI: "http://myErrorURL/no-ad", Host: "myerrorurl", Action: DOMAIN_DROP_URI}, violations[0])
+
+	assert.Empty(t, v.Errors)
+	if assert.Len(t, v.Ads, 2) {
+		inline := v.Ads[0].InLine
+		assert.Empty(t, inline.Impressions)
+		assert.Empty(t, inline.Errors)
+		assert.Empty(t, inline.Creatives[0].Linear.TrackingEvents)
+		assert.Nil(t, inline.Creatives[0].Linear.VideoClicks.ClickTrackings)
+		assert.Len(t, inline.Creatives[0].Linear.VideoClicks.ClickThroughs, 1)
+		assert.Empty(t, inline.Creatives[1].CompanionAds.Companions[0].TrackingEvents)
+	}
+
+	assert.Nil(t, v.ApplyDomainPolicy(DomainPolicy{Block: []string{"mytrackingurl"}}))
+}
+
+func TestApplyDomainPolicyModes(t *testing.T) {
+	v := loadPolicyFixture(t)
+	violations := v.ApplyDomainPolicy(DomainPolicy{Block: []string{"*.example.com"}, Mode: DOMAIN_DROP_CREATIVE})
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, "Ad[1]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[0]", violations[0].Path)
+		assert.Equal(t, DOMAIN_DROP_CREATIVE, violations[0].Action)
+	}
+	if assert.Len(t, v.Ads, 2) {
+		assert.Len(t, v.Ads[0].InLine.Creatives, 2)
+		if assert.Len(t, v.Ads[1].InLine.Creatives, 1) {
+			assert.Nil(t, v.Ads[1].InLine.Creatives[0].Linear)
+		}
+	}
+
+	v = loadPolicyFixture(t)
+	v.Ads[0].Sequence, v.Ads[1].Sequence = 2, 1
+	violations = v.ApplyDomainPolicy(DomainPolicy{Block: []string{"*.example.com"}, Mode: DOMAIN_REJECT_AD})
+	if assert.Len(t, violations, 1) && assert.Len(t, v.Ads, 1) {
+		assert.Equal(t, DOMAIN_REJECT_AD, violations[0].Action)
+		assert.Equal(t, "601364", v.Ads[0].ID)
+		assert.Equal(t, 1, v.Ads[0].Sequence)
+	}
+
+	// a disallowed wrapped tag always rejects the ad
+	w := &VAST{Ads: []Ad{{Wrapper: &Wrapper{VASTAdTagURI: CDATAString{"https://bad.com/vast"}}}}}
+	violations = w.ApplyDomainPolicy(DomainPolicy{Allow: []string{"good.com"}})
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, DOMAIN_REJECT_AD, violations[0].Action)
+	}
+	assert.Empty(t, w.Ads)
+}
+
+func TestThirdPartyDomains(t *testing.T) {
+	v := loadPolicyFixture(t)
+	assert.Equal(t, []string{
+		"cdn.example.com",
+		"demo.tremormedia.com",
+		"myerrorurl",
+		"www.tremormedia.com",
+	}, v.Ads[1].ThirdPartyDomains("mytrackingurl"))
+	assert.Equal(t, []string{"cdn.example.com", "myerrorurl", "mytrackingurl"}, v.Ads[1].ThirdPartyDomains("*.tremormedia.com"))
+}
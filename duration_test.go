@@ -68,4 +68,8 @@ func TestDurationUnmarshal(t *testing.T) {
 	assert.EqualError(t, d.UnmarshalText([]byte("00:00:00.-1")), "invalid duration: 00:00:00.-1")
 	assert.EqualError(t, d.UnmarshalText([]byte("00:00:00.1000")), "invalid duration: 00:00:00.1000")
 	assert.EqualError(t, d.UnmarshalText([]byte("00h01m")), "invalid duration: 00h01m")
+	d = 0
+	if assert.NoError(t, d.UnmarshalText([]byte("100:00:00"))) {
+		assert.Equal(t, Duration(100*time.Hour), d)
+	}
 }
@@ -0,0 +1,32 @@
+package vast
+
+// VAST 3.0 error codes, sent in the [ERRORCODE] macro of error URIs.
+const (
+	ERROR_XML_PARSING             = 100
+	ERROR_SCHEMA_VALIDATION       = 101
+	ERROR_VERSION_NOT_SUPPORTED   = 102
+	ERROR_TRAFFICKING             = 200
+	ERROR_LINEARITY               = 201
+	ERROR_DURATION                = 202
+	ERROR_SIZE                    = 203
+	ERROR_WRAPPER                 = 300
+	ERROR_WRAPPER_TIMEOUT         = 301
+	ERROR_WRAPPER_LIMIT           = 302
+	ERROR_NO_ADS_AFTER_WRAPPER    = 303
+	ERROR_LINEAR                  = 400
+	ERROR_MEDIA_NOT_FOUND         = 401
+	ERROR_MEDIA_TIMEOUT           = 402
+	ERROR_MEDIA_NOT_SUPPORTED     = 403
+	ERROR_MEDIA_DISPLAY           = 405
+	ERROR_NONLINEAR               = 500
+	ERROR_NONLINEAR_SIZE          = 501
+	ERROR_NONLINEAR_FETCH         = 502
+	ERROR_NONLINEAR_NOT_SUPPORTED = 503
+	ERROR_COMPANION               = 600
+	ERROR_COMPANION_SIZE          = 601
+	ERROR_COMPANION_REQUIRED      = 602
+	ERROR_COMPANION_FETCH         = 603
+	ERROR_COMPANION_NOT_SUPPORTED = 604
+	ERROR_UNDEFINED               = 900
+	ERROR_VPAID                   = 901
+)
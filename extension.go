@@ -1,17 +1,27 @@
 package vast
 
 import (
+	"bytes"
+	"encoding/json"
 	"encoding/xml"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 )
 
 // Extension represent arbitrary XML provided by the platform to extend the
 // VAST response or by custom trackers.
 type Extension struct {
-	Type           string            `xml:"type,attr,omitempty"`
-	Name           string            `xml:"name,attr,omitempty"`
-	CustomTracking []Tracking        `xml:"CustomTracking>Tracking,omitempty"`
-	Data           []byte            `xml:",innerxml"`
-	Attributes     map[string]string `xml:"-"`
+	Type           string     `xml:"type,attr,omitempty" json:"type,omitempty"`
+	Name           string     `xml:"name,attr,omitempty" json:"name,omitempty"`
+	CustomTracking []Tracking `xml:"CustomTracking>Tracking,omitempty" json:"custom_tracking,omitempty"`
+	Data           []byte     `xml:",innerxml" json:"data,omitempty"`
+	// Custom attributes, prefixed ones keyed as prefix:name
+	Attributes map[string]string `xml:"-" json:"attributes,omitempty"`
+	// Namespace declarations by prefix, "" for the default namespace
+	Namespaces map[string]string `xml:"-" json:"namespaces,omitempty"`
 }
 
 // the extension type as a middleware in the encoding process.
@@ -36,16 +46,7 @@ func (e Extension) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
 		e2 = extensionNoCT{Type: e.Type, Name: e.Name, Data: e.Data}
 	}
 
-	// custom attributes
-	if len(e.Attributes) > 0 {
-		for name, value := range e.Attributes {
-			start.Attr = append(start.Attr, xml.Attr{
-				Name:  xml.Name{Space: "", Local: name},
-				Value: value,
-			})
-		}
-	}
-
+	start.Attr = append(start.Attr, e.attrs()...)
 	return enc.EncodeElement(e2, start)
 }
 
@@ -66,14 +67,280 @@ func (e *Extension) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error
 		e.Data = e2.Data
 	}
 
-	// if extension have attribute
-	if len(start.Attr) > 0 {
-		for name, value := range e.Attributes {
-			if name != "name" && name != "type" {
-				e.Attributes[name] = value
+	e.setAttrs(start.Attr)
+	return nil
+}
+
+// namespace of the reserved xml: prefix
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// setAttrs keeps the namespace declarations and custom attributes of an
+// <Extension> start tag, as translated by encoding/xml.
+func (e *Extension) setAttrs(attrs []xml.Attr) {
+	isDecl := func(name xml.Name) bool {
+		return name.Space == "xmlns" || (name.Space == "" && name.Local == "xmlns")
+	}
+
+	prefixes := map[string]string{xmlNamespace: "xml"}
+	for _, attr := range attrs {
+		if !isDecl(attr.Name) {
+			continue
+		}
+		prefix := ""
+		if attr.Name.Space == "xmlns" {
+			prefix = attr.Name.Local
+			prefixes[attr.Value] = prefix
+		}
+		if e.Namespaces == nil {
+			e.Namespaces = make(map[string]string)
+		}
+		e.Namespaces[prefix] = attr.Value
+	}
+
+	for _, attr := range attrs {
+		name := attr.Name.Local
+		switch {
+		case isDecl(attr.Name):
+			continue
+		case attr.Name.Space == "":
+			if name == "name" || name == "type" {
+				continue
+			}
+		default:
+			// the namespace of a prefix declared out of the element is kept
+			// in place of the prefix
+			prefix, ok := prefixes[attr.Name.Space]
+			if !ok {
+				prefix = attr.Name.Space
+			}
+			name = prefix + ":" + name
+		}
+		if e.Attributes == nil {
+			e.Attributes = make(map[string]string)
+		}
+		e.Attributes[name] = attr.Value
+	}
+}
+
+// attrs returns the namespace declarations and custom attributes of e, sorted
+// so the output is stable.
+func (e Extension) attrs() []xml.Attr {
+	var attrs []xml.Attr
+	for _, prefix := range sortedKeys(e.Namespaces) {
+		name := "xmlns"
+		if prefix != "" {
+			name += ":" + prefix
+		}
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: name}, Value: e.Namespaces[prefix]})
+	}
+	for _, key := range sortedKeys(e.Attributes) {
+		name := xml.Name{Local: key}
+		if i := strings.LastIndexByte(key, ':'); i > 0 {
+			// a namespace rather than a declared prefix is declared by the
+			// encoder
+			if prefix := key[:i]; prefix != "xml" && e.Namespaces[prefix] == "" {
+				name = xml.Name{Space: prefix, Local: key[i+1:]}
 			}
 		}
+		attrs = append(attrs, xml.Attr{Name: name, Value: e.Attributes[key]})
+	}
+	return attrs
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// the JSON encoding of an Extension
+type extensionJSON struct {
+	Type           string            `json:"type,omitempty"`
+	Name           string            `json:"name,omitempty"`
+	Attributes     map[string]string `json:"attributes,omitempty"`
+	Namespaces     map[string]string `json:"namespaces,omitempty"`
+	CustomTracking []Tracking        `json:"custom_tracking,omitempty"`
+	Data           string            `json:"data,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. The data is encoded as
+// a raw XML string.
+func (e Extension) MarshalJSON() ([]byte, error) {
+	return json.Marshal(extensionJSON{
+		Type:           e.Type,
+		Name:           e.Name,
+		Attributes:     e.Attributes,
+		Namespaces:     e.Namespaces,
+		CustomTracking: e.CustomTracking,
+		Data:           string(e.Data),
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (e *Extension) UnmarshalJSON(data []byte) error {
+	var e2 extensionJSON
+	if err := json.Unmarshal(data, &e2); err != nil {
+		return err
+	}
+	*e = Extension{
+		Type:           e2.Type,
+		Name:           e2.Name,
+		Attributes:     e2.Attributes,
+		Namespaces:     e2.Namespaces,
+		CustomTracking: e2.CustomTracking,
+	}
+	if e2.Data != "" {
+		e.Data = []byte(e2.Data)
+	}
+	return nil
+}
+
+// ExtensionCodec converts between an Extension and a typed Go value. Codecs are
+// registered per Extension.Type with RegisterExtensionCodec.
+type ExtensionCodec interface {
+	// DecodeExtension returns the typed value held by e.
+	DecodeExtension(e *Extension) (interface{}, error)
+	// EncodeExtension fills e (attributes and data) from the typed value v.
+	EncodeExtension(v interface{}, e *Extension) error
+}
+
+type registeredExtension struct {
+	typ   string
+	codec ExtensionCodec
+}
+
+var extensionRegistry = struct {
+	sync.RWMutex
+	byType   map[string]registeredExtension
+	byGoType map[reflect.Type]registeredExtension
+}{
+	byType:   make(map[string]registeredExtension),
+	byGoType: make(map[reflect.Type]registeredExtension),
+}
+
+// RegisterExtension registers the Go type of prototype as the payload of
+// extensions with the given type. The payload is decoded with encoding/xml as
+// if the type described the <Extension> element itself: its attributes map to
+// `xml:",attr"` fields and its children to regular fields. It panics if
+// prototype is nil.
+func RegisterExtension(typ string, prototype interface{}) {
+	t := prototypeType("RegisterExtension", typ, prototype)
+	RegisterExtensionCodec(typ, prototype, xmlExtensionCodec{t})
+}
+
+// RegisterExtensionCodec registers a custom codec for extensions with the given
+// type. The Go type of prototype is used by NewExtension to find the codec back
+// from a value. It panics if prototype is nil.
+func RegisterExtensionCodec(typ string, prototype interface{}, codec ExtensionCodec) {
+	t := prototypeType("RegisterExtensionCodec", typ, prototype)
+	r := registeredExtension{typ: typ, codec: codec}
+
+	extensionRegistry.Lock()
+	extensionRegistry.byType[typ] = r
+	extensionRegistry.byGoType[t] = r
+	extensionRegistry.Unlock()
+}
+
+// prototypeType returns the Go type of a prototype, pointers dereferenced
+func prototypeType(fn, typ string, prototype interface{}) reflect.Type {
+	t := reflect.TypeOf(prototype)
+	if t == nil {
+		panic(fmt.Sprintf("vast: %s of nil prototype for type %q", fn, typ))
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func lookupExtension(typ string) (registeredExtension, bool) {
+	extensionRegistry.RLock()
+	defer extensionRegistry.RUnlock()
+	r, ok := extensionRegistry.byType[typ]
+	return r, ok
+}
+
+// Decode returns the typed value of the extension using the codec registered
+// for its type. Extensions of an unknown type decode to a copy of their raw
+// inner XML ([]byte).
+func (e Extension) Decode() (interface{}, error) {
+	r, ok := lookupExtension(e.Type)
+	if !ok {
+		return append([]byte(nil), e.Data...), nil
+	}
+	v, err := r.codec.DecodeExtension(&e)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s extension: %s", e.Type, err)
+	}
+	return v, nil
+}
+
+// NewExtension builds an Extension from a value whose type has been registered
+// with RegisterExtension or RegisterExtensionCodec.
+func NewExtension(v interface{}) (Extension, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	extensionRegistry.RLock()
+	r, ok := extensionRegistry.byGoType[t]
+	extensionRegistry.RUnlock()
+	if !ok {
+		return Extension{}, fmt.Errorf("unregistered extension value %T", v)
+	}
+
+	e := Extension{Type: r.typ}
+	if err := r.codec.EncodeExtension(v, &e); err != nil {
+		return Extension{}, fmt.Errorf("invalid %s extension: %s", r.typ, err)
+	}
+	return e, nil
+}
+
+// xmlExtensionCodec is the default codec, mapping the <Extension> element on a
+// Go type with encoding/xml.
+type xmlExtensionCodec struct {
+	t reflect.Type
+}
+
+// rawExtension captures the attributes and inner XML of an encoded element.
+type rawExtension struct {
+	Attr []xml.Attr `xml:",any,attr"`
+	Data []byte     `xml:",innerxml"`
+}
+
+func (c xmlExtensionCodec) DecodeExtension(e *Extension) (interface{}, error) {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	if err := enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "Extension"}, Attr: e.attrs()}); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	buf.Write(e.Data)
+	buf.WriteString("</Extension>")
+
+	v := reflect.New(c.t)
+	if err := xml.Unmarshal(buf.Bytes(), v.Interface()); err != nil {
+		return nil, err
 	}
+	return v.Interface(), nil
+}
 
+func (c xmlExtensionCodec) EncodeExtension(v interface{}, e *Extension) error {
+	var buf bytes.Buffer
+	if err := xml.NewEncoder(&buf).EncodeElement(v, xml.StartElement{Name: xml.Name{Local: "Extension"}}); err != nil {
+		return err
+	}
+	var raw rawExtension
+	if err := xml.Unmarshal(buf.Bytes(), &raw); err != nil {
+		return err
+	}
+	e.setAttrs(raw.Attr)
+	e.Data = raw.Data
 	return nil
 }
@@ -1,6 +1,7 @@
 package vast
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"testing"
 
@@ -77,3 +78,158 @@ func TestExtensionGeneric(t *testing.T) {
 	// assert the resulting marshaled extension
 	assert.Equal(t, string(extensionData), string(xmlExtensionOutput))
 }
+
+func TestExtensionDecodeRegistered(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/inline_extensions.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	exts := v.Ads[0].InLine.Extensions
+	geo, err := exts[0].Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Geo{Country: "US", Bandwidth: 3, BandwidthKbps: 1680}, geo)
+	}
+
+	// unknown types fall back to the raw inner XML
+	raw, err := exts[2].Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, exts[2].Data, raw)
+	}
+}
+
+func TestExtensionDecodeSpotX(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/spotx_vpaid.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	exts := v.Ads[0].InLine.Extensions
+	price, err := exts[0].Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, &LRPricing{Price: ExtensionPrice{Model: "CPM", Currency: "USD", Source: "spotxchange", Value: "3.06"}}, price)
+	}
+	count, err := exts[1].Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, &SpotXCount{TotalAvailable: 1}, count)
+	}
+}
+
+func TestExtensionAttributes(t *testing.T) {
+	var e Extension
+	assert.NoError(t, xml.Unmarshal([]byte(`<Extension type="waterfall" fallback_index="2"></Extension>`), &e))
+	assert.Equal(t, map[string]string{"fallback_index": "2"}, e.Attributes)
+
+	w, err := e.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Waterfall{FallbackIndex: 2}, w)
+	}
+}
+
+func TestNewExtension(t *testing.T) {
+	e, err := NewExtension(&Waterfall{FallbackIndex: 1})
+	if assert.NoError(t, err) {
+		b, err := xml.Marshal(e)
+		assert.NoError(t, err)
+		assert.Equal(t, `<Extension fallback_index="1" type="waterfall"></Extension>`, string(b))
+	}
+
+	e, err = NewExtension(AdVerifications{Verifications: []Verification{{
+		Vendor:              "vendor.com-omid",
+		JavaScriptResources: []JavaScriptResource{{APIFramework: "omid", URI: "https://vendor.com/omid.js"}},
+	}}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, EXT_AD_VERIFICATIONS, e.Type)
+		v, err := e.Decode()
+		if assert.NoError(t, err) {
+			av := v.(*AdVerifications)
+			if assert.Len(t, av.Verifications, 1) {
+				assert.Equal(t, "vendor.com-omid", av.Verifications[0].Vendor)
+				assert.Equal(t, "https://vendor.com/omid.js", av.Verifications[0].JavaScriptResources[0].URI)
+			}
+		}
+	}
+
+	_, err = NewExtension(struct{}{})
+	assert.EqualError(t, err, "unregistered extension value struct {}")
+}
+
+type testExtension struct {
+	Value string `xml:"Value"`
+}
+
+func TestRegisterExtension(t *testing.T) {
+	RegisterExtension("test", testExtension{})
+
+	e, err := NewExtension(testExtension{Value: "foo"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "test", e.Type)
+		assert.Equal(t, "<Value>foo</Value>", string(e.Data))
+	}
+
+	v, err := Extension{Type: "test", Data: []byte("<Value>bar</Value>")}.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, &testExtension{Value: "bar"}, v)
+	}
+
+	_, err = Extension{Type: "test", Data: []byte("<Value>")}.Decode()
+	assert.Error(t, err)
+}
+
+func TestRegisterExtensionNil(t *testing.T) {
+	recovered := func(f func()) (r interface{}) {
+		defer func() { r = recover() }()
+		f()
+		return nil
+	}
+	assert.Equal(t, `vast: RegisterExtension of nil prototype for type "x"`, recovered(func() { RegisterExtension("x", nil) }))
+	assert.Equal(t, `vast: RegisterExtensionCodec of nil prototype for type "x"`, recovered(func() { RegisterExtensionCodec("x", nil, nil) }))
+	_, ok := lookupExtension("x")
+	assert.False(t, ok)
+}
+
+func TestExtensionNamespaces(t *testing.T) {
+	data := `<Extension type="x" xmlns:foo="urn:x" foo:bar="1" xml:lang="en" fallback_index="2"><foo:v>1</foo:v></Extension>`
+	var e Extension
+	if !assert.NoError(t, xml.Unmarshal([]byte(data), &e)) {
+		return
+	}
+	assert.Equal(t, "x", e.Type)
+	assert.Equal(t, map[string]string{"foo": "urn:x"}, e.Namespaces)
+	assert.Equal(t, map[string]string{"foo:bar": "1", "xml:lang": "en", "fallback_index": "2"}, e.Attributes)
+
+	b, err := xml.Marshal(e)
+	assert.NoError(t, err)
+	assert.Equal(t, `<Extension xmlns:foo="urn:x" fallback_index="2" foo:bar="1" xml:lang="en" type="x"><foo:v>1</foo:v></Extension>`, string(b))
+
+	var e2 Extension
+	if assert.NoError(t, xml.Unmarshal(b, &e2)) {
+		assert.Equal(t, e, e2)
+	}
+	assert.Equal(t, []Extension{e}, cloneExtensions([]Extension{e}))
+}
+
+func TestExtensionJSON(t *testing.T) {
+	var e Extension
+	assert.NoError(t, xml.Unmarshal(extensionData, &e))
+	e.Attributes = map[string]string{"fallback_index": "1"}
+
+	b, err := json.Marshal(e)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"testCustomTracking","attributes":{"fallback_index":"1"},"data":"\u003cSkippableAdType\u003eGeneric\u003c/SkippableAdType\u003e"}`, string(b))
+
+	var e2 Extension
+	assert.NoError(t, json.Unmarshal(b, &e2))
+	assert.Equal(t, e, e2)
+
+	// custom trackers
+	var ct Extension
+	assert.NoError(t, xml.Unmarshal(extensionCustomTracking, &ct))
+	b, err = json.Marshal(ct)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"testCustomTracking","custom_tracking":[{"event":"event.1","uri":"http://event.1"},{"event":"event.2","uri":"http://event.2"}]}`, string(b))
+
+	var ct2 Extension
+	assert.NoError(t, json.Unmarshal(b, &ct2))
+	assert.Equal(t, ct, ct2)
+}
@@ -0,0 +1,75 @@
+package vast
+
+// Extension types registered by default, see RegisterExtension.
+const (
+	EXT_AD_VERIFICATIONS = "AdVerifications"
+	EXT_WATERFALL        = "waterfall"
+	EXT_GEO              = "geo"
+	EXT_LR_PRICING       = "LR-Pricing"
+	EXT_SPOTX_COUNT      = "SpotX-Count"
+)
+
+func init() {
+	RegisterExtension(EXT_AD_VERIFICATIONS, AdVerifications{})
+	RegisterExtension(EXT_WATERFALL, Waterfall{})
+	RegisterExtension(EXT_GEO, Geo{})
+	RegisterExtension(EXT_LR_PRICING, LRPricing{})
+	RegisterExtension(EXT_SPOTX_COUNT, SpotXCount{})
+}
+
+// AdVerifications is the IAB Open Measurement extension used to carry
+// <AdVerifications> in VAST 3 documents.
+type AdVerifications struct {
+	Verifications []Verification `xml:"AdVerifications>Verification"`
+}
+
+// Verification contains the resources needed to run a third party
+// verification script.
+type Verification struct {
+	// Identifies the verification vendor, e.g. "company.com-omid"
+	Vendor string `xml:"vendor,attr,omitempty"`
+	// Scripts to load for the verification
+	JavaScriptResources []JavaScriptResource `xml:"JavaScriptResource,omitempty"`
+	// Opaque parameters passed to the verification script
+	VerificationParameters *CDATAString `xml:",omitempty"`
+	// Verification trackers, such as verificationNotExecuted
+	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty"`
+}
+
+// JavaScriptResource is a verification script URI
+type JavaScriptResource struct {
+	APIFramework    string `xml:"apiFramework,attr,omitempty"`
+	BrowserOptional bool   `xml:"browserOptional,attr,omitempty"`
+	URI             string `xml:",cdata"`
+}
+
+// Waterfall is the IMA extension giving the position of the ad in the
+// publisher waterfall.
+type Waterfall struct {
+	FallbackIndex int `xml:"fallback_index,attr"`
+}
+
+// Geo is the DFP extension describing the viewer location and bandwidth.
+type Geo struct {
+	Country       string `xml:",omitempty"`
+	Bandwidth     int    `xml:",omitempty"`
+	BandwidthKbps int    `xml:",omitempty"`
+}
+
+// LRPricing is the LiveRail/SpotX pricing extension.
+type LRPricing struct {
+	Price ExtensionPrice `xml:"Price"`
+}
+
+// ExtensionPrice is the price carried by an LR-Pricing extension
+type ExtensionPrice struct {
+	Model    string `xml:"model,attr,omitempty"`
+	Currency string `xml:"currency,attr,omitempty"`
+	Source   string `xml:"source,attr,omitempty"`
+	Value    string `xml:",cdata"`
+}
+
+// SpotXCount is the SpotX extension giving the number of available ads.
+type SpotXCount struct {
+	TotalAvailable int `xml:"total_available"`
+}
@@ -0,0 +1,154 @@
+package vast
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Handler serves the VAST document returned by a decision function, with the
+// CORS headers HTML5 and IMA players need. When there is no ad, it serves an
+// empty <VAST> whose error URIs carry ERROR_NO_ADS_AFTER_WRAPPER (303).
+type Handler struct {
+	// Decide returns the document to serve, nil or a no ad document (see
+	// IsNoAd) when no ad is available. An error, or a nil Decide, is served as
+	// an empty document with ERROR_UNDEFINED (900), after being passed to
+	// OnError.
+	Decide func(r *http.Request) (*VAST, error)
+	// Error URIs of empty documents, added to those of the decided one. The
+	// [ERRORCODE] macro is expanded.
+	Errors []string
+	// Version of empty documents, see NoAd
+	Version string
+	// Host patterns of the origins allowed to read the response with
+	// credentials (see DomainPolicy). If empty, any origin may read it without
+	// credentials.
+	Origins []string
+	// Compress the response when the client accepts gzip
+	Gzip bool
+	// OnError, if set, is called with the errors of Decide
+	OnError func(r *http.Request, err error)
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.cors(w, r)
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST, OPTIONS")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	v, err := h.decide(r)
+	if err != nil {
+		if h.OnError != nil {
+			h.OnError(r, err)
+		}
+		v = h.empty(nil, ERROR_UNDEFINED)
+	} else if v == nil || v.IsNoAd() {
+		v = h.empty(v, ERROR_NO_ADS_AFTER_WRAPPER)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(v); err != nil {
+		if h.OnError != nil {
+			h.OnError(r, err)
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "application/xml; charset=utf-8")
+	header.Set("Cache-Control", "no-store")
+	header.Add("Vary", "Accept-Encoding")
+	body := buf.Bytes()
+	if h.Gzip && acceptsGzip(r) {
+		var gz bytes.Buffer
+		zw := gzip.NewWriter(&gz)
+		zw.Write(body)
+		zw.Close()
+		body = gz.Bytes()
+		header.Set("Content-Encoding", "gzip")
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// decide calls Decide, failing if it is not set
+func (h *Handler) decide(r *http.Request) (*VAST, error) {
+	if h.Decide == nil {
+		return nil, errors.New("nil decide function")
+	}
+	return h.Decide(r)
+}
+
+// cors sets the CORS headers for an allowed origin. Players request ads with
+// credentials, so an allowed origin is echoed rather than *. Without Origins,
+// any origin may read the response but not with credentials.
+func (h *Handler) cors(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	origin := r.Header.Get("Origin")
+	switch {
+	case origin == "" || len(h.Origins) == 0:
+		header.Set("Access-Control-Allow-Origin", "*")
+	case matchHostPatterns(uriHost(origin), h.Origins):
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Set("Access-Control-Allow-Credentials", "true")
+	default:
+		return
+	}
+	if r.Method == http.MethodOptions {
+		header.Set("Access-Control-Allow-Methods", "GET, HEAD, POST, OPTIONS")
+		if req := r.Header.Get("Access-Control-Request-Headers"); req != "" {
+			header.Set("Access-Control-Allow-Headers", req)
+		}
+		header.Set("Access-Control-Max-Age", "600")
+	}
+}
+
+// empty returns a document without ads carrying the error URIs of v and of
+// the handler, see NoAd
+func (h *Handler) empty(v *VAST, code int) *VAST {
+	version := h.Version
+	var errorURIs []string
+	if v != nil {
+		if v.Version != "" {
+			version = v.Version
+		}
+		errorURIs = cdataURIs(v.Errors)
+	}
+	return NoAd(version, code, append(errorURIs, h.Errors...)...)
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(enc, ";")
+		if !strings.EqualFold(strings.TrimSpace(parts[0]), "gzip") {
+			continue
+		}
+		for _, p := range parts[1:] {
+			if q := strings.TrimSpace(p); strings.HasPrefix(q, "q=") {
+				if f, err := strconv.ParseFloat(q[2:], 64); err == nil && f == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
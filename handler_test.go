@@ -0,0 +1,144 @@
+package vast
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	h := &Handler{Decide: func(r *http.Request) (*VAST, error) {
+		ad := podAd("a", 0, 15*time.Second)
+		return &VAST{Version: "3.0", Ads: []Ad{ad}}, nil
+	}}
+
+	req := httptest.NewRequest("GET", "http://ads.example.com/vast", nil)
+	req.Header.Set("Origin", "https://player.example.com")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", rec.Header().Get("Content-Type"))
+	// any origin, without credentials
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+	assert.True(t, strings.HasPrefix(rec.Body.String(), xml.Header))
+
+	var v VAST
+	if assert.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &v)) && assert.Len(t, v.Ads, 1) {
+		assert.Equal(t, "a", v.Ads[0].ID)
+	}
+}
+
+func TestHandlerNoAd(t *testing.T) {
+	h := &Handler{
+		Decide: func(r *http.Request) (*VAST, error) {
+			return nil, nil
+		},
+		Errors: []string{"https://ads.example.com/err?code=[ERRORCODE]"},
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "http://ads.example.com/vast", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, xml.Header+`<VAST version="3.0"><Error><![CDATA[https://ads.example.com/err?code=303]]></Error></VAST>`, rec.Body.String())
+
+	// decision errors
+	var logged error
+	h.Decide = func(r *http.Request) (*VAST, error) {
+		return nil, errors.New("timeout")
+	}
+	h.OnError = func(r *http.Request, err error) {
+		logged = err
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "http://ads.example.com/vast", nil))
+	assert.EqualError(t, logged, "timeout")
+	assert.Contains(t, rec.Body.String(), "code=900")
+
+	// no decision function
+	h.Decide = nil
+	rec = httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "http://ads.example.com/vast", nil))
+	})
+	assert.EqualError(t, logged, "nil decide function")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "code=900")
+
+	// empty decided document, with its own errors
+	h.Decide = func(r *http.Request) (*VAST, error) {
+		return &VAST{Version: "4.0", Errors: []CDATAString{{"https://dsp.example.com/e/[ERRORCODE]"}}}, nil
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "http://ads.example.com/vast", nil))
+	assert.Equal(t, xml.Header+`<VAST version="4.0"><Error><![CDATA[https://dsp.example.com/e/303]]></Error><Error><![CDATA[https://ads.example.com/err?code=303]]></Error></VAST>`, rec.Body.String())
+}
+
+func TestHandlerCORS(t *testing.T) {
+	h := &Handler{
+		Decide:  func(r *http.Request) (*VAST, error) { return nil, nil },
+		Origins: []string{"*.example.com"},
+	}
+
+	req := httptest.NewRequest("OPTIONS", "http://ads.example.com/vast", nil)
+	req.Header.Set("Origin", "https://player.example.com")
+	req.Header.Set("Access-Control-Request-Headers", "X-Requested-With")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://player.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "X-Requested-With", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), "GET")
+
+	req = httptest.NewRequest("GET", "http://ads.example.com/vast", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("DELETE", "http://ads.example.com/vast", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestHandlerGzip(t *testing.T) {
+	h := &Handler{Decide: func(r *http.Request) (*VAST, error) { return nil, nil }, Gzip: true}
+
+	req := httptest.NewRequest("GET", "http://ads.example.com/vast", nil)
+	req.Header.Set("Accept-Encoding", "deflate, gzip;q=0.8")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(rec.Body)
+	if assert.NoError(t, err) {
+		b, err := ioutil.ReadAll(zr)
+		assert.NoError(t, err)
+		assert.Equal(t, xml.Header+`<VAST version="3.0"></VAST>`, string(b))
+	}
+
+	req.Header.Set("Accept-Encoding", "gzip;q=0")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, xml.Header+`<VAST version="3.0"></VAST>`, rec.Body.String())
+
+	// HEAD
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("HEAD", "http://ads.example.com/vast", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.NotEmpty(t, rec.Header().Get("Content-Length"))
+}
@@ -0,0 +1,85 @@
+package vast
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Limits bounds the resources used when decoding untrusted documents. Zero
+// fields are not enforced.
+type Limits struct {
+	// Maximum size of the document in bytes
+	MaxSize int64
+	// Maximum nesting depth of elements
+	MaxDepth int
+	// Maximum number of elements in the document
+	MaxElements int
+	// Maximum number of attributes of a single element
+	MaxAttributes int
+	// Maximum length in bytes of a single text or CDATA section
+	MaxCDATA int
+}
+
+// DefaultLimits are the limits used by DecodeSafe when none are given. They
+// leave plenty of room for real world responses, including large VPAID
+// AdParameters.
+var DefaultLimits = Limits{
+	MaxSize:       2 << 20,
+	MaxDepth:      64,
+	MaxElements:   20000,
+	MaxAttributes: 64,
+	MaxCDATA:      512 << 10,
+}
+
+// LimitError is returned when a document exceeds the decoding limits or
+// contains a DOCTYPE or entity declaration.
+type LimitError struct {
+	// The exceeded limit: size, depth, elements, attributes, cdata or doctype
+	Limit string
+	// The configured maximum, 0 for doctype
+	Max int64
+	// Position of the offending token, 0 for size
+	Line   int
+	Column int
+}
+
+func (e *LimitError) Error() string {
+	if e.Limit == "doctype" {
+		return fmt.Sprintf("vast: DOCTYPE and entity declarations are not allowed (%d:%d)", e.Line, e.Column)
+	}
+	if e.Line == 0 {
+		return fmt.Sprintf("vast: document exceeds %s limit of %d", e.Limit, e.Max)
+	}
+	return fmt.Sprintf("vast: document exceeds %s limit of %d (%d:%d)", e.Limit, e.Max, e.Line, e.Column)
+}
+
+// Code returns the VAST error code matching the error.
+func (e *LimitError) Code() int {
+	return ERROR_XML_PARSING
+}
+
+// DecodeSafe decodes an untrusted document, enforcing opts.Limits or
+// DefaultLimits if none are set. DOCTYPE and entity declarations are rejected.
+func DecodeSafe(r io.Reader, opts DecodeOptions) (*DecodeResult, error) {
+	if opts.Limits == nil {
+		limits := DefaultLimits
+		opts.Limits = &limits
+	}
+	return Decode(r, opts)
+}
+
+// readLimited reads at most max bytes from r, 0 meaning no limit
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	if max <= 0 {
+		return ioutil.ReadAll(r)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, &LimitError{Limit: "size", Max: max}
+	}
+	return data, nil
+}
@@ -0,0 +1,57 @@
+package vast
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeSafe(t *testing.T) {
+	f, err := os.Open("testdata/spotx_vpaid.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	res, err := DecodeSafe(f, DecodeOptions{})
+	if assert.NoError(t, err) {
+		assert.Len(t, res.VAST.Ads, 1)
+	}
+}
+
+func TestDecodeLimits(t *testing.T) {
+	doc := "<VAST>\n  <Ad id=\"1\" sequence=\"1\"><InLine><AdTitle><![CDATA[title]]></AdTitle></InLine></Ad>\n</VAST>"
+
+	tests := []struct {
+		limits Limits
+		err    string
+	}{
+		{Limits{MaxSize: 10}, "vast: document exceeds size limit of 10"},
+		{Limits{MaxDepth: 3}, "vast: document exceeds depth limit of 3 (2:35)"},
+		{Limits{MaxElements: 2}, "vast: document exceeds elements limit of 2 (2:27)"},
+		{Limits{MaxAttributes: 1}, "vast: document exceeds attributes limit of 1 (2:3)"},
+		{Limits{MaxCDATA: 4}, "vast: document exceeds cdata limit of 4 (2:44)"},
+	}
+	for _, tt := range tests {
+		limits := tt.limits
+		_, err := Decode(strings.NewReader(doc), DecodeOptions{Limits: &limits})
+		if assert.IsType(t, &LimitError{}, err) {
+			assert.Equal(t, ERROR_XML_PARSING, err.(*LimitError).Code())
+		}
+		assert.EqualError(t, err, tt.err)
+	}
+
+	_, err := Decode(strings.NewReader(doc), DecodeOptions{Limits: &Limits{MaxDepth: 4, MaxCDATA: 5}})
+	assert.NoError(t, err)
+}
+
+func TestDecodeSafeDoctype(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<!DOCTYPE VAST [<!ENTITY lol "lol">]>
+<VAST version="3.0"><Ad><InLine><AdTitle>&lol;</AdTitle></InLine></Ad></VAST>`
+
+	_, err := DecodeSafe(strings.NewReader(doc), DecodeOptions{})
+	assert.EqualError(t, err, "vast: DOCTYPE and entity declarations are not allowed (2:1)")
+}
@@ -0,0 +1,361 @@
+package vast
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ManifestOptions selects the media file of each ad of a Manifest.
+type ManifestOptions struct {
+	// Accepted MIME types in order of preference, any type if empty
+	Formats []string
+	// Size of the player: the media file closest to it is chosen, the largest
+	// if zero
+	Width, Height int
+	// Maximum bitrate in Kbps, no limit if zero
+	MaxBitrate int
+}
+
+// Manifest is a resolved document flattened for players which do not read
+// VAST. It is meant to be emitted as JSON.
+type Manifest struct {
+	Version string `json:"version,omitempty"`
+	// Ads in playback order: the pod if any, the stand-alone ads otherwise
+	Ads []ManifestAd `json:"ads"`
+	// Stand-alone ads of a pod, to play in place of pod ads which fail
+	Alternates []ManifestAd `json:"alternates,omitempty"`
+	// Error URLs of a no ad response
+	Errors []string `json:"errors,omitempty"`
+	// Ads left out because they can't be played from a manifest
+	Skipped []ManifestSkipped `json:"skipped,omitempty"`
+}
+
+// ManifestSkipped is an ad left out of a Manifest.
+type ManifestSkipped struct {
+	// Index of the ad in the document
+	Index  int    `json:"index"`
+	ID     string `json:"id,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ManifestAd is an ad of a Manifest.
+type ManifestAd struct {
+	ID       string `json:"id,omitempty"`
+	Sequence int    `json:"sequence,omitempty"`
+	AdSystem string `json:"ad_system,omitempty"`
+	Title    string `json:"title,omitempty"`
+
+	Media      ManifestMedia `json:"media"`
+	Duration   Duration      `json:"duration"`
+	SkipOffset *Offset       `json:"skip_offset,omitempty"`
+	// Ad parameters of interactive media files
+	AdParameters string `json:"ad_parameters,omitempty"`
+	ClickThrough string `json:"click_through,omitempty"`
+
+	// Tracking URLs by event: the linear tracking events plus the impression
+	// (TRACK_IMPRESSION), viewable impression (TRACK_VIEWABLE) and click
+	// tracking (TRACK_CLICK) URLs
+	Tracking map[string][]string `json:"tracking,omitempty"`
+	// Progress tracking URLs, which fire at an offset
+	Progress []ManifestProgress `json:"progress,omitempty"`
+	Errors   []string           `json:"errors,omitempty"`
+
+	Companions []ManifestCompanion `json:"companions,omitempty"`
+	Icons      []ManifestIcon      `json:"icons,omitempty"`
+}
+
+// ManifestMedia is the media file chosen for an ad.
+type ManifestMedia struct {
+	URL          string `json:"url"`
+	Type         string `json:"type,omitempty"`
+	Delivery     string `json:"delivery,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	Bitrate      int    `json:"bitrate,omitempty"`
+	APIFramework string `json:"api_framework,omitempty"`
+}
+
+// ManifestProgress is a progress tracking URL.
+type ManifestProgress struct {
+	Offset Offset `json:"offset"`
+	URL    string `json:"url"`
+}
+
+// ManifestResource tells how to render a companion or an icon.
+type ManifestResource struct {
+	// "static", "iframe" or "html"
+	Type string `json:"type"`
+	// MIME type of a static resource
+	CreativeType string `json:"creative_type,omitempty"`
+	// URL of a static or iframe resource
+	URL string `json:"url,omitempty"`
+	// Markup of an html resource
+	HTML string `json:"html,omitempty"`
+}
+
+// ManifestCompanion is a companion banner of an ad.
+type ManifestCompanion struct {
+	ID            string              `json:"id,omitempty"`
+	Width         int                 `json:"width"`
+	Height        int                 `json:"height"`
+	AdSlotID      string              `json:"ad_slot_id,omitempty"`
+	AltText       string              `json:"alt_text,omitempty"`
+	Resource      ManifestResource    `json:"resource"`
+	ClickThrough  string              `json:"click_through,omitempty"`
+	ClickTrackers []string            `json:"click_trackers,omitempty"`
+	Tracking      map[string][]string `json:"tracking,omitempty"`
+}
+
+// ManifestIcon is an icon, such as AdChoices, displayed over an ad.
+type ManifestIcon struct {
+	Program       string           `json:"program,omitempty"`
+	Width         int              `json:"width"`
+	Height        int              `json:"height"`
+	XPosition     string           `json:"x_position,omitempty"`
+	YPosition     string           `json:"y_position,omitempty"`
+	Offset        Offset           `json:"offset"`
+	Duration      Duration         `json:"duration,omitempty"`
+	Resource      ManifestResource `json:"resource"`
+	ClickThrough  string           `json:"click_through,omitempty"`
+	ClickTrackers []string         `json:"click_trackers,omitempty"`
+	ViewTrackers  []string         `json:"view_trackers,omitempty"`
+}
+
+// Manifest flattens a resolved document into a player manifest. Ads must be
+// InLine ads with a Linear creative and a media file matching opts, the others
+// are listed in Skipped. The first Linear creative of each ad is used, along
+// with the companions of all its creatives. Companions and icons without a
+// resource are left out. An error is returned when the document has ads but
+// none of them fits, that of the first one skipped.
+func (v *VAST) Manifest(opts ManifestOptions) (*Manifest, error) {
+	m := &Manifest{
+		Version: v.Version,
+		Ads:     make([]ManifestAd, 0, len(v.Ads)),
+		Errors:  cdataURLs(v.Errors),
+	}
+	add := func(ads []*Ad, to *[]ManifestAd) {
+		for _, ad := range ads {
+			a, err := ad.manifest(opts)
+			if err != nil {
+				m.Skipped = append(m.Skipped, ManifestSkipped{Index: v.adIndex(ad), ID: ad.ID, Reason: err.Error()})
+				continue
+			}
+			*to = append(*to, a)
+		}
+	}
+	if pod := v.Pod(); len(pod) > 0 {
+		add(pod, &m.Ads)
+		add(v.Buffet(), &m.Alternates)
+	} else {
+		add(v.Buffet(), &m.Ads)
+	}
+	if len(m.Ads) == 0 && len(m.Alternates) == 0 && len(m.Skipped) > 0 {
+		return nil, fmt.Errorf("bad ad[%d] %s", m.Skipped[0].Index, m.Skipped[0].Reason)
+	}
+	return m, nil
+}
+
+func (ad *Ad) manifest(opts ManifestOptions) (ManifestAd, error) {
+	inline := ad.InLine
+	if inline == nil {
+		return ManifestAd{}, errors.New("not inline")
+	}
+	var linear *Linear
+	for _, c := range inline.Creatives {
+		if c.Linear != nil {
+			linear = c.Linear
+			break
+		}
+	}
+	if linear == nil {
+		return ManifestAd{}, errors.New("empty linear")
+	}
+	media, ok := selectMediaFile(linear.MediaFiles, opts)
+	if !ok {
+		return ManifestAd{}, errors.New("no media file matches")
+	}
+
+	a := ManifestAd{
+		ID:         ad.ID,
+		Sequence:   ad.Sequence,
+		AdSystem:   describeAdSystem(inline.AdSystem),
+		Title:      strings.TrimSpace(inline.AdTitle.CDATA),
+		Duration:   linear.Duration,
+		SkipOffset: linear.SkipOffset,
+		Errors:     cdataURLs(inline.Errors),
+		Media: ManifestMedia{
+			URL:          strings.TrimSpace(media.URI),
+			Type:         media.Type,
+			Delivery:     media.Delivery,
+			Width:        media.Width,
+			Height:       media.Height,
+			Bitrate:      media.Bitrate,
+			APIFramework: media.APIFramework,
+		},
+	}
+	if linear.AdParameters != nil {
+		a.AdParameters = linear.AdParameters.Parameters
+	}
+
+	for _, imp := range inline.Impressions {
+		a.Tracking = appendURL(a.Tracking, TRACK_IMPRESSION, imp.URI)
+	}
+	for _, view := range inline.ViewableImpression {
+		a.Tracking = appendURL(a.Tracking, TRACK_VIEWABLE, view.URI)
+	}
+	for _, t := range linear.TrackingEvents {
+		if t.Event == "progress" && t.Offset != nil {
+			if url := strings.TrimSpace(t.URI); url != "" {
+				a.Progress = append(a.Progress, ManifestProgress{Offset: *t.Offset, URL: url})
+			}
+			continue
+		}
+		a.Tracking = appendURL(a.Tracking, t.Event, t.URI)
+	}
+	if clicks := linear.VideoClicks; clicks != nil {
+		for _, c := range clicks.ClickThroughs {
+			if url := strings.TrimSpace(c.URI); url != "" {
+				a.ClickThrough = url
+				break
+			}
+		}
+		for _, c := range clicks.ClickTrackings {
+			a.Tracking = appendURL(a.Tracking, TRACK_CLICK, c.URI)
+		}
+	}
+
+	if linear.Icons != nil {
+		for _, icon := range linear.Icons.Icon {
+			res, ok := manifestResource(icon.StaticResource, icon.IFrameResource, icon.HTMLResource)
+			if !ok {
+				continue
+			}
+			a.Icons = append(a.Icons, ManifestIcon{
+				Program:       icon.Program,
+				Width:         icon.Width,
+				Height:        icon.Height,
+				XPosition:     icon.XPosition,
+				YPosition:     icon.YPosition,
+				Offset:        icon.Offset,
+				Duration:      icon.Duration,
+				Resource:      res,
+				ClickThrough:  strings.TrimSpace(icon.IconClickThrough.CDATA),
+				ClickTrackers: cdataURLs(icon.IconClickTrackings),
+				ViewTrackers:  cdataURLs(icon.IconViewTrackings),
+			})
+		}
+	}
+	for _, c := range inline.Creatives {
+		if c.CompanionAds == nil {
+			continue
+		}
+		for _, comp := range c.CompanionAds.Companions {
+			res, ok := manifestResource(comp.StaticResource, comp.IFrameResource, comp.HTMLResource)
+			if !ok {
+				continue
+			}
+			mc := ManifestCompanion{
+				ID:            comp.ID,
+				Width:         comp.Width,
+				Height:        comp.Height,
+				AdSlotID:      comp.AdSlotID,
+				AltText:       comp.AltText,
+				Resource:      res,
+				ClickThrough:  strings.TrimSpace(comp.CompanionClickThrough.CDATA),
+				ClickTrackers: cdataURLs(comp.CompanionClickTracking),
+			}
+			for _, t := range comp.TrackingEvents {
+				mc.Tracking = appendURL(mc.Tracking, t.Event, t.URI)
+			}
+			a.Companions = append(a.Companions, mc)
+		}
+	}
+	return a, nil
+}
+
+// selectMediaFile returns the media file of s preferred by opts: the first
+// accepted format, then the size closest to the player or the largest one,
+// then the highest bitrate
+func selectMediaFile(s []MediaFile, opts ManifestOptions) (MediaFile, bool) {
+	rank := func(m MediaFile) int {
+		if len(opts.Formats) == 0 {
+			return 0
+		}
+		for i, format := range opts.Formats {
+			if strings.EqualFold(strings.TrimSpace(format), m.Type) {
+				return i
+			}
+		}
+		return -1
+	}
+	area := func(m MediaFile) int {
+		if opts.Width == 0 && opts.Height == 0 {
+			return -m.Width * m.Height
+		}
+		d := m.Width*m.Height - opts.Width*opts.Height
+		if d < 0 {
+			d = -d
+		}
+		return d
+	}
+
+	better := func(a, b MediaFile) bool {
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra < rb
+		}
+		if aa, ab := area(a), area(b); aa != ab {
+			return aa < ab
+		}
+		return a.Bitrate > b.Bitrate
+	}
+
+	best, found := MediaFile{}, false
+	for _, m := range s {
+		if strings.TrimSpace(m.URI) == "" || rank(m) < 0 {
+			continue
+		}
+		if opts.MaxBitrate > 0 && m.Bitrate > opts.MaxBitrate {
+			continue
+		}
+		if !found || better(m, best) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// manifestResource returns the first resource set
+func manifestResource(static *StaticResource, iframe CDATAString, html *HTMLResource) (ManifestResource, bool) {
+	switch {
+	case static != nil && strings.TrimSpace(static.URI) != "":
+		return ManifestResource{Type: "static", CreativeType: static.CreativeType, URL: strings.TrimSpace(static.URI)}, true
+	case strings.TrimSpace(iframe.CDATA) != "":
+		return ManifestResource{Type: "iframe", URL: strings.TrimSpace(iframe.CDATA)}, true
+	case html != nil && strings.TrimSpace(html.HTML) != "":
+		return ManifestResource{Type: "html", HTML: html.HTML}, true
+	}
+	return ManifestResource{}, false
+}
+
+func appendURL(m map[string][]string, event, uri string) map[string][]string {
+	uri = strings.TrimSpace(uri)
+	if uri == "" {
+		return m
+	}
+	if m == nil {
+		m = map[string][]string{}
+	}
+	m[event] = append(m[event], uri)
+	return m
+}
+
+func cdataURLs(s []CDATAString) []string {
+	var urls []string
+	for _, c := range s {
+		if uri := strings.TrimSpace(c.CDATA); uri != "" {
+			urls = append(urls, uri)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,152 @@
+package vast
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/liverail-vast2-linear-companion.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	m, err := v.Manifest(ManifestOptions{})
+	if !assert.NoError(t, err) || !assert.Len(t, m.Ads, 1) {
+		return
+	}
+	ad := m.Ads[0]
+	assert.Equal(t, "LiveRail LR_DELIVERY_VERSION", ad.AdSystem)
+	assert.Equal(t, Duration(11*time.Second), ad.Duration)
+	assert.Equal(t, "http://cdn.liverail.com/adasset4/1331/229/331/me.mp4", ad.Media.URL)
+	assert.Equal(t, "video/mp4", ad.Media.Type)
+	assert.Contains(t, ad.ClickThrough, "metric=clickthru")
+	assert.Len(t, ad.Tracking[TRACK_IMPRESSION], 55)
+	assert.Len(t, ad.Tracking[TRACK_FIRST_QUARTILE], 1)
+	assert.Len(t, ad.Errors, 1)
+	if assert.Len(t, ad.Companions, 3) {
+		comp := ad.Companions[0]
+		assert.Equal(t, 300, comp.Width)
+		assert.Equal(t, 60, comp.Height)
+		assert.Equal(t, ManifestResource{Type: "static", CreativeType: "image/jpeg", URL: "http://cdn.liverail.com/adasset/229/331/300x60.jpg"}, comp.Resource)
+		assert.Contains(t, comp.ClickThrough, "metric=cclickthru")
+		assert.Len(t, comp.Tracking["creativeView"], 1)
+	}
+
+	b, err := json.Marshal(m)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), `"duration":"00:00:11"`)
+		assert.Contains(t, string(b), `"resource":{"type":"static"`)
+	}
+}
+
+func TestManifestIcons(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_adaptv_attempt_attr.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	m, err := v.Manifest(ManifestOptions{})
+	if !assert.NoError(t, err) || !assert.Len(t, m.Ads, 1) {
+		return
+	}
+	ad := m.Ads[0]
+	if assert.Len(t, ad.Icons, 1) {
+		icon := ad.Icons[0]
+		assert.Equal(t, "DAA", icon.Program)
+		assert.Equal(t, "right", icon.XPosition)
+		assert.Equal(t, "top", icon.YPosition)
+		assert.Equal(t, "https://s.aolcdn.com/ads/adchoices.png", icon.Resource.URL)
+		assert.Equal(t, "https://adinfo.aol.com", icon.ClickThrough)
+	}
+}
+
+func TestManifestMediaSelection(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_adaptv_attempt_attr.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	media := func(opts ManifestOptions) ManifestMedia {
+		m, err := v.Manifest(opts)
+		if !assert.NoError(t, err) {
+			return ManifestMedia{}
+		}
+		return m.Ads[0].Media
+	}
+
+	// largest, then highest bitrate
+	got := media(ManifestOptions{})
+	assert.Equal(t, 960, got.Width)
+	assert.Equal(t, 1024, got.Bitrate)
+
+	// preferred format, closest size
+	got = media(ManifestOptions{Formats: []string{"video/webm", "video/mp4"}, Width: 640, Height: 360})
+	assert.Equal(t, "video/webm", got.Type)
+	assert.Equal(t, 640, got.Width)
+	assert.Equal(t, 1024, got.Bitrate)
+
+	// bitrate limit
+	got = media(ManifestOptions{Formats: []string{"video/mp4"}, MaxBitrate: 600})
+	assert.Equal(t, 960, got.Width)
+	assert.Equal(t, 512, got.Bitrate)
+
+	_, err = v.Manifest(ManifestOptions{Formats: []string{"application/x-mpegURL"}})
+	assert.EqualError(t, err, "bad ad[0] no media file matches")
+}
+
+func TestManifestPod(t *testing.T) {
+	v := &VAST{Version: "3.0", Ads: []Ad{
+		podAd("standalone", 0, 30*time.Second),
+		podAd("second", 2, 15*time.Second),
+		podAd("first", 1, 15*time.Second),
+	}}
+	for _, ad := range v.Ads {
+		ad.InLine.Creatives[0].Linear.MediaFiles = []MediaFile{{Delivery: "progressive", Type: "video/mp4", URI: "http://media/" + ad.ID}}
+	}
+	v.Ads[2].InLine.Creatives[0].Linear.TrackingEvents = []Tracking{
+		{Event: "progress", Offset: &Offset{Duration: durationPtr(Duration(5 * time.Second))}, URI: "http://track/5s"},
+	}
+
+	// the stand-alone ad is an alternate of the pod
+	m, err := v.Manifest(ManifestOptions{})
+	if !assert.NoError(t, err) || !assert.Len(t, m.Ads, 2) {
+		return
+	}
+	assert.Equal(t, "first", m.Ads[0].ID)
+	assert.Equal(t, "second", m.Ads[1].ID)
+	if assert.Len(t, m.Alternates, 1) {
+		assert.Equal(t, "standalone", m.Alternates[0].ID)
+	}
+	if assert.Len(t, m.Ads[0].Progress, 1) {
+		assert.Equal(t, "http://track/5s", m.Ads[0].Progress[0].URL)
+	}
+
+	// unusable ads are skipped
+	v.Ads[1] = Ad{ID: "wrapped", Sequence: 2, Wrapper: &Wrapper{}}
+	m, err = v.Manifest(ManifestOptions{})
+	if assert.NoError(t, err) && assert.Len(t, m.Ads, 1) {
+		assert.Equal(t, "first", m.Ads[0].ID)
+		assert.Len(t, m.Alternates, 1)
+		assert.Equal(t, []ManifestSkipped{{Index: 1, ID: "wrapped", Reason: "not inline"}}, m.Skipped)
+	}
+
+	// without a pod, the stand-alone ads are played
+	m, err = (&VAST{Ads: []Ad{v.Ads[0], v.Ads[0]}}).Manifest(ManifestOptions{})
+	if assert.NoError(t, err) {
+		assert.Len(t, m.Ads, 2)
+		assert.Empty(t, m.Alternates)
+	}
+
+	// an error when no ad fits
+	v.Ads = v.Ads[1:2]
+	_, err = v.Manifest(ManifestOptions{})
+	assert.EqualError(t, err, "bad ad[0] not inline")
+
+	m, err = (&VAST{Version: "3.0"}).Manifest(ManifestOptions{})
+	if assert.NoError(t, err) {
+		assert.Empty(t, m.Ads)
+	}
+}
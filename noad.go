@@ -0,0 +1,39 @@
+package vast
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NoAd returns a response without ads carrying the error URIs, which the
+// player requests to report the cause. Their [ERRORCODE] macro is set to code,
+// e.g. ERROR_NO_ADS_AFTER_WRAPPER, and left as is if code is zero. The version
+// defaults to 3.0.
+func NoAd(version string, code int, errorURIs ...string) *VAST {
+	if version == "" {
+		version = "3.0"
+	}
+	v := &VAST{Version: version}
+	macro := strings.NewReplacer("[ERRORCODE]", strconv.Itoa(code))
+	for _, uri := range errorURIs {
+		if uri = strings.TrimSpace(uri); uri == "" {
+			continue
+		}
+		if code != 0 {
+			uri = macro.Replace(uri)
+		}
+		v.Errors = append(v.Errors, CDATAString{uri})
+	}
+	return v
+}
+
+// IsNoAd reports whether v is a no ad response: it has no ads, or none of its
+// ads holds an InLine or a Wrapper element.
+func (v *VAST) IsNoAd() bool {
+	for _, ad := range v.Ads {
+		if ad.InLine != nil || ad.Wrapper != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package vast
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoAd(t *testing.T) {
+	v := NoAd("", ERROR_NO_ADS_AFTER_WRAPPER, "https://ads.example.com/err?code=[ERRORCODE]", " ", "https://dsp.example.com/e")
+	assert.Equal(t, "3.0", v.Version)
+	assert.Empty(t, v.Ads)
+	assert.True(t, v.IsNoAd())
+
+	b, err := xml.Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, `<VAST version="3.0"><Error><![CDATA[https://ads.example.com/err?code=303]]></Error><Error><![CDATA[https://dsp.example.com/e]]></Error></VAST>`, string(b))
+
+	// macro left for the player
+	v = NoAd("4.0", 0, "https://ads.example.com/err?code=[ERRORCODE]")
+	assert.Equal(t, "4.0", v.Version)
+	assert.Equal(t, "https://ads.example.com/err?code=[ERRORCODE]", v.Errors[0].CDATA)
+}
+
+func TestIsNoAd(t *testing.T) {
+	assert.True(t, (&VAST{}).IsNoAd())
+	assert.True(t, (&VAST{Ads: []Ad{{ID: "empty"}}}).IsNoAd())
+	assert.False(t, (&VAST{Ads: []Ad{{ID: "empty"}, {Wrapper: &Wrapper{}}}}).IsNoAd())
+	assert.False(t, (&VAST{Ads: []Ad{{InLine: &InLine{}}}}).IsNoAd())
+}
+
+func TestDecodeNoAd(t *testing.T) {
+	res, err := Decode(strings.NewReader(`<VAST version="3.0"><Error><![CDATA[http://err]]></Error></VAST>`), DecodeOptions{})
+	if assert.NoError(t, err) {
+		assert.True(t, res.NoAd)
+		assert.Len(t, res.VAST.Errors, 1)
+	}
+
+	res, err = Decode(strings.NewReader(`<VAST version="2.0"><Ad id="1"></Ad></VAST>`), DecodeOptions{})
+	if assert.NoError(t, err) {
+		assert.True(t, res.NoAd)
+	}
+
+	v, _, _, err := loadFixture("testdata/vast_inline_linear.xml")
+	if assert.NoError(t, err) {
+		b, _ := xml.Marshal(v)
+		res, err = Decode(strings.NewReader(string(b)), DecodeOptions{})
+		if assert.NoError(t, err) {
+			assert.False(t, res.NoAd)
+		}
+	}
+
+	// invalid documents are errors, not no ad responses
+	res, err = Decode(strings.NewReader(`<html><body>504 Gateway Timeout</body></html>`), DecodeOptions{})
+	assert.EqualError(t, err, "expected element <VAST> but have <html>")
+	assert.Nil(t, res)
+}
@@ -0,0 +1,96 @@
+package vast
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// Normalize trims the whitespace around the URIs (see EachURI) and the text
+// fields (AdSystem, AdTitle, Description, Advertiser, Pricing, AltText) of the
+// document. HTML resources, ad parameters and extension payloads are left
+// untouched.
+func (v *VAST) Normalize() {
+	v.EachURI(func(_ string, uri *string) {
+		*uri = strings.TrimSpace(*uri)
+	})
+
+	for _, ad := range v.Ads {
+		if ad.InLine != nil {
+			inline := ad.InLine
+			normalizeAdSystem(inline.AdSystem)
+			inline.AdTitle.CDATA = strings.TrimSpace(inline.AdTitle.CDATA)
+			inline.Description.CDATA = strings.TrimSpace(inline.Description.CDATA)
+			inline.Advertiser = strings.TrimSpace(inline.Advertiser)
+			inline.Pricing = strings.TrimSpace(inline.Pricing)
+			for _, c := range inline.Creatives {
+				if c.CompanionAds != nil {
+					for i := range c.CompanionAds.Companions {
+						comp := &c.CompanionAds.Companions[i]
+						comp.AltText = strings.TrimSpace(comp.AltText)
+					}
+				}
+			}
+		} else if ad.Wrapper != nil {
+			normalizeAdSystem(ad.Wrapper.AdSystem)
+			for _, c := range ad.Wrapper.Creatives {
+				if c.CompanionAds != nil {
+					for i := range c.CompanionAds.Companions {
+						comp := &c.CompanionAds.Companions[i]
+						comp.AltText = strings.TrimSpace(comp.AltText)
+					}
+				}
+			}
+		}
+	}
+}
+
+func normalizeAdSystem(s *AdSystem) {
+	if s != nil {
+		s.Name = strings.TrimSpace(s.Name)
+		s.Version = strings.TrimSpace(s.Version)
+	}
+}
+
+// CompactXML removes the insignificant whitespace of a raw document: text made
+// only of whitespace that sits between two tags. Text and CDATA content, and
+// whitespace next to them, is kept byte for byte. The document is returned
+// unchanged if it is not well formed.
+func CompactXML(buf []byte) []byte {
+	type token struct {
+		start, end int64
+		blank      bool // whitespace only, outside of CDATA
+		text       bool // text or CDATA
+	}
+
+	var tokens []token
+	dec := xml.NewDecoder(bytes.NewReader(buf))
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return buf
+		}
+		t := token{start: start, end: dec.InputOffset()}
+		if cd, ok := tok.(xml.CharData); ok {
+			t.text = true
+			raw := buf[t.start:t.end]
+			t.blank = len(bytes.TrimSpace(cd)) == 0 && !bytes.HasPrefix(raw, []byte("<![CDATA["))
+		}
+		tokens = append(tokens, t)
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(buf))
+	for i, t := range tokens {
+		if t.blank && (i == 0 || !tokens[i-1].text) && (i == len(tokens)-1 || !tokens[i+1].text) {
+			continue
+		}
+		out.Write(buf[t.start:t.end])
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,51 @@
+package vast
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactXML(t *testing.T) {
+	doc := "<VAST>\n  <Ad>\n\t<InLine>\n    <AdTitle> title </AdTitle>\n    <Description>\n      <![CDATA[ line1\n\tline2 ]]>\n    </Description>\n  </InLine></Ad>\n</VAST>\n"
+	assert.Equal(t, "<VAST><Ad><InLine><AdTitle> title </AdTitle><Description>\n      <![CDATA[ line1\n\tline2 ]]>\n    </Description></InLine></Ad></VAST>", string(CompactXML([]byte(doc))))
+
+	// not well formed documents are left untouched
+	assert.Equal(t, "<VAST>\n<Ad>", string(CompactXML([]byte("<VAST>\n<Ad>"))))
+	assert.Equal(t, "<VAST></VAST>", ClearStr("<VAST>\n\t</VAST>"))
+}
+
+func TestCompactXMLKeepsCDATA(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/spotx_vpaid.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var orig, compact VAST
+	assert.NoError(t, xml.Unmarshal(b, &orig))
+	assert.NoError(t, xml.Unmarshal(ClearBuf(b), &compact))
+
+	// HTML and AdParameters payloads are byte exact
+	assert.Equal(t, orig.Ads[0].InLine.Creatives[0].Linear.AdParameters.Parameters, compact.Ads[0].InLine.Creatives[0].Linear.AdParameters.Parameters)
+	assert.Equal(t, orig.Ads[0].InLine.Creatives[1].CompanionAds.Companions[1].HTMLResource.HTML, compact.Ads[0].InLine.Creatives[1].CompanionAds.Companions[1].HTMLResource.HTML)
+	assert.True(t, len(ClearBuf(b)) < len(b))
+}
+
+func TestNormalize(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/extraspaces_vpaid.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	linear := v.Ads[0].InLine.Creatives[0].Linear
+	params := linear.AdParameters.Parameters
+	v.Ads[0].InLine.AdTitle.CDATA = "\n  IntegralAds_VAST_2_0_Ad_Wrapper\t"
+	v.Normalize()
+
+	assert.Equal(t, "https://dummy.com/dummmy.js", linear.MediaFiles[0].URI)
+	assert.Equal(t, "IntegralAds_VAST_2_0_Ad_Wrapper", v.Ads[0].InLine.AdTitle.CDATA)
+	assert.Equal(t, "SpotXchange", v.Ads[0].InLine.AdSystem.Name)
+	assert.Equal(t, params, linear.AdParameters.Parameters)
+}
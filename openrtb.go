@@ -0,0 +1,251 @@
+package vast
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenRTB video protocols
+const (
+	PROTOCOL_VAST_1         = 1
+	PROTOCOL_VAST_2         = 2
+	PROTOCOL_VAST_3         = 3
+	PROTOCOL_VAST_1_WRAPPER = 4
+	PROTOCOL_VAST_2_WRAPPER = 5
+	PROTOCOL_VAST_3_WRAPPER = 6
+	PROTOCOL_VAST_4         = 7
+	PROTOCOL_VAST_4_WRAPPER = 8
+)
+
+// OpenRTB markup type of video bids
+const MTYPE_VIDEO = 2
+
+// Bid is the OpenRTB 2.x bid object, limited to the fields describing a video
+// ad. Other fields are carried by Ext.
+type Bid struct {
+	ID       string          `json:"id"`
+	ImpID    string          `json:"impid"`
+	Price    float64         `json:"price"`
+	NURL     string          `json:"nurl,omitempty"`
+	BURL     string          `json:"burl,omitempty"`
+	LURL     string          `json:"lurl,omitempty"`
+	AdM      string          `json:"adm,omitempty"`
+	AdID     string          `json:"adid,omitempty"`
+	ADomain  []string        `json:"adomain,omitempty"`
+	CID      string          `json:"cid,omitempty"`
+	CrID     string          `json:"crid,omitempty"`
+	Cat      []string        `json:"cat,omitempty"`
+	Protocol int             `json:"protocol,omitempty"`
+	DealID   string          `json:"dealid,omitempty"`
+	W        int             `json:"w,omitempty"`
+	H        int             `json:"h,omitempty"`
+	Dur      int             `json:"dur,omitempty"`
+	MType    int             `json:"mtype,omitempty"`
+	Ext      json.RawMessage `json:"ext,omitempty"`
+}
+
+// AuctionMacros holds the values of the OpenRTB substitution macros. Empty
+// ids default to those of the bid.
+type AuctionMacros struct {
+	// ${AUCTION_ID}
+	AuctionID string
+	// ${AUCTION_BID_ID}
+	BidID string
+	// ${AUCTION_IMP_ID}
+	ImpID string
+	// ${AUCTION_SEAT_ID}
+	SeatID string
+	// ${AUCTION_AD_ID}
+	AdID string
+	// ${AUCTION_PRICE}, the clearing price
+	Price float64
+	// ${AUCTION_CURRENCY}
+	Currency string
+	// ${AUCTION_MBR}, the market bid ratio
+	MBR float64
+	// ${AUCTION_LOSS}, the loss reason code
+	Loss int
+	// ${AUCTION_MIN_TO_WIN}
+	MinToWin float64
+}
+
+// SubstituteMacros replaces the OpenRTB ${AUCTION_...} macros in the adm, nurl,
+// burl and lurl of the bid. Values are query escaped, which also makes them
+// safe within the CDATA or text of the adm.
+func (b *Bid) SubstituteMacros(m AuctionMacros) {
+	def := func(value, bid string) string {
+		if value == "" {
+			return bid
+		}
+		return value
+	}
+	price := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	esc := url.QueryEscape
+	r := strings.NewReplacer(
+		"${AUCTION_ID}", esc(m.AuctionID),
+		"${AUCTION_BID_ID}", esc(def(m.BidID, b.ID)),
+		"${AUCTION_IMP_ID}", esc(def(m.ImpID, b.ImpID)),
+		"${AUCTION_SEAT_ID}", esc(m.SeatID),
+		"${AUCTION_AD_ID}", esc(def(m.AdID, b.AdID)),
+		"${AUCTION_PRICE}", price(m.Price),
+		"${AUCTION_CURRENCY}", esc(m.Currency),
+		"${AUCTION_MBR}", price(m.MBR),
+		"${AUCTION_LOSS}", strconv.Itoa(m.Loss),
+		"${AUCTION_MIN_TO_WIN}", price(m.MinToWin),
+	)
+	b.AdM = r.Replace(b.AdM)
+	b.NURL = r.Replace(b.NURL)
+	b.BURL = r.Replace(b.BURL)
+	b.LURL = r.Replace(b.LURL)
+}
+
+// DecodeVAST decodes the VAST document of the bid, see Decode. The document is
+// the adm of the bid or, when adm is empty, the response to its nurl read with
+// fetch. Macros should be substituted first, see SubstituteMacros.
+func (b *Bid) DecodeVAST(fetch func(uri string) (io.ReadCloser, error), opts DecodeOptions) (*DecodeResult, error) {
+	if strings.TrimSpace(b.AdM) != "" {
+		return Decode(strings.NewReader(b.AdM), opts)
+	}
+	if b.NURL == "" {
+		return nil, errors.New("empty adm")
+	}
+	if fetch == nil {
+		return nil, errors.New("empty adm, nurl not fetched")
+	}
+	body, err := fetch(b.NURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return Decode(body, opts)
+}
+
+// VideoInfo describes a document as the video object of an OpenRTB
+// impression would.
+type VideoInfo struct {
+	// MIME types of the media files
+	MIMEs []string `json:"mimes,omitempty"`
+	// Protocols of the document, one of the PROTOCOL_ values per ad type
+	Protocols []int `json:"protocols,omitempty"`
+	// Size of the largest media file
+	W int `json:"w,omitempty"`
+	H int `json:"h,omitempty"`
+	// Duration in seconds of the pod if any, of the longest ad otherwise
+	Dur int `json:"dur,omitempty"`
+}
+
+// VideoInfo infers the MIME types, protocols, size and duration of the
+// document. Protocols are derived from the major version of the document.
+func (v *VAST) VideoInfo() VideoInfo {
+	var info VideoInfo
+	mimes := map[string]bool{}
+	protocols := map[int]bool{}
+	major := strings.SplitN(strings.TrimSpace(v.Version), ".", 2)[0]
+
+	var longest Duration
+	for i := range v.Ads {
+		ad := &v.Ads[i]
+		if p := protocol(major, ad.Wrapper != nil); p > 0 {
+			protocols[p] = true
+		}
+		if d := ad.Duration(); d > longest {
+			longest = d
+		}
+		if ad.InLine == nil {
+			continue
+		}
+		for _, c := range ad.InLine.Creatives {
+			if c.Linear == nil {
+				continue
+			}
+			for _, m := range c.Linear.MediaFiles {
+				if m.Type != "" {
+					mimes[m.Type] = true
+				}
+				if m.Width*m.Height > info.W*info.H {
+					info.W, info.H = m.Width, m.Height
+				}
+			}
+		}
+	}
+
+	for mime := range mimes {
+		info.MIMEs = append(info.MIMEs, mime)
+	}
+	sort.Strings(info.MIMEs)
+	for p := range protocols {
+		info.Protocols = append(info.Protocols, p)
+	}
+	sort.Ints(info.Protocols)
+
+	dur := longest
+	if pod := v.PodDuration(); pod > 0 {
+		dur = pod
+	}
+	info.Dur = int((time.Duration(dur) + time.Second - 1) / time.Second)
+	return info
+}
+
+func protocol(major string, wrapper bool) int {
+	switch major {
+	case "1":
+		if wrapper {
+			return PROTOCOL_VAST_1_WRAPPER
+		}
+		return PROTOCOL_VAST_1
+	case "2":
+		if wrapper {
+			return PROTOCOL_VAST_2_WRAPPER
+		}
+		return PROTOCOL_VAST_2
+	case "3":
+		if wrapper {
+			return PROTOCOL_VAST_3_WRAPPER
+		}
+		return PROTOCOL_VAST_3
+	case "4":
+		if wrapper {
+			return PROTOCOL_VAST_4_WRAPPER
+		}
+		return PROTOCOL_VAST_4
+	}
+	return 0
+}
+
+// AdM returns the document encoded as the adm of a bid.
+func (v *VAST) AdM() (string, error) {
+	var buf bytes.Buffer
+	if err := xml.NewEncoder(&buf).Encode(v); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// SetVAST sets the adm of the bid to the document, along with the protocol,
+// size and duration inferred from it, see VideoInfo. The protocol is the
+// wrapper protocol if the document holds a wrapper.
+func (b *Bid) SetVAST(v *VAST) error {
+	adm, err := v.AdM()
+	if err != nil {
+		return err
+	}
+	info := v.VideoInfo()
+	b.AdM = adm
+	b.MType = MTYPE_VIDEO
+	b.W, b.H, b.Dur = info.W, info.H, info.Dur
+	b.Protocol = 0
+	if n := len(info.Protocols); n > 0 {
+		b.Protocol = info.Protocols[n-1]
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package vast
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBidSubstituteMacros(t *testing.T) {
+	b := &Bid{
+		ID:    "bid-1",
+		ImpID: "1",
+		AdM:   `<VAST version="3.0"><Ad><InLine><Impression><![CDATA[http://imp?p=${AUCTION_PRICE}&a=${AUCTION_ID}]]></Impression></InLine></Ad></VAST>`,
+		NURL:  "http://win?p=${AUCTION_PRICE}&b=${AUCTION_BID_ID}&i=${AUCTION_IMP_ID}&c=${AUCTION_CURRENCY}",
+		BURL:  "http://bill?p=${AUCTION_PRICE}",
+		LURL:  "http://loss?r=${AUCTION_LOSS}&m=${AUCTION_MIN_TO_WIN}",
+	}
+	b.SubstituteMacros(AuctionMacros{AuctionID: "auc", Price: 1.25, Currency: "USD", Loss: 102, MinToWin: 1.3})
+
+	assert.Contains(t, b.AdM, "http://imp?p=1.25&a=auc")
+	assert.Equal(t, "http://win?p=1.25&b=bid-1&i=1&c=USD", b.NURL)
+	assert.Equal(t, "http://bill?p=1.25", b.BURL)
+	assert.Equal(t, "http://loss?r=102&m=1.3", b.LURL)
+}
+
+func TestBidSubstituteMacrosEscaped(t *testing.T) {
+	b := &Bid{
+		AdM: `<VAST version="3.0"><Ad><InLine>` +
+			`<Impression><![CDATA[http://imp?a=${AUCTION_ID}&s=${AUCTION_SEAT_ID}]]></Impression>` +
+			`<Impression>http://imp2?a=${AUCTION_ID}</Impression></InLine></Ad></VAST>`,
+		NURL: "http://win?a=${AUCTION_ID}&s=${AUCTION_SEAT_ID}",
+	}
+	b.SubstituteMacros(AuctionMacros{AuctionID: "a&b=c", SeatID: "<seat>"})
+
+	assert.Equal(t, "http://win?a=a%26b%3Dc&s=%3Cseat%3E", b.NURL)
+	res, err := b.DecodeVAST(nil, DecodeOptions{})
+	if assert.NoError(t, err) {
+		imps := res.VAST.Ads[0].InLine.Impressions
+		assert.Equal(t, "http://imp?a=a%26b%3Dc&s=%3Cseat%3E", imps[0].URI)
+		assert.Equal(t, "http://imp2?a=a%26b%3Dc", imps[1].URI)
+	}
+}
+
+func TestBidDecodeVAST(t *testing.T) {
+	b := &Bid{AdM: `<VAST version="3.0"><Ad id="a"><InLine><AdSystem>x</AdSystem></InLine></Ad></VAST>`}
+	res, err := b.DecodeVAST(nil, DecodeOptions{})
+	if assert.NoError(t, err) && assert.Len(t, res.VAST.Ads, 1) {
+		assert.Equal(t, "a", res.VAST.Ads[0].ID)
+	}
+
+	// from nurl
+	b = &Bid{NURL: "http://win?p=1"}
+	var fetched string
+	fetch := func(uri string) (io.ReadCloser, error) {
+		fetched = uri
+		return ioutil.NopCloser(strings.NewReader(`<VAST version="2.0"><Ad id="n"></Ad></VAST>`)), nil
+	}
+	res, err = b.DecodeVAST(fetch, DecodeOptions{})
+	if assert.NoError(t, err) && assert.Len(t, res.VAST.Ads, 1) {
+		assert.Equal(t, "n", res.VAST.Ads[0].ID)
+	}
+	assert.Equal(t, "http://win?p=1", fetched)
+
+	_, err = b.DecodeVAST(func(string) (io.ReadCloser, error) { return nil, errors.New("timeout") }, DecodeOptions{})
+	assert.EqualError(t, err, "timeout")
+	_, err = b.DecodeVAST(nil, DecodeOptions{})
+	assert.EqualError(t, err, "empty adm, nurl not fetched")
+	_, err = (&Bid{}).DecodeVAST(fetch, DecodeOptions{})
+	assert.EqualError(t, err, "empty adm")
+}
+
+func TestVideoInfo(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_adaptv_attempt_attr.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	info := v.VideoInfo()
+	assert.Equal(t, []string{"video/mp4", "video/webm", "video/x-flv"}, info.MIMEs)
+	assert.Equal(t, []int{PROTOCOL_VAST_3}, info.Protocols)
+	assert.Equal(t, 960, info.W)
+	assert.Equal(t, 540, info.H)
+	assert.Equal(t, 15, info.Dur)
+
+	w, _, _, err := loadFixture("testdata/vast_wrapper_linear_1.xml")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{PROTOCOL_VAST_2_WRAPPER}, w.VideoInfo().Protocols)
+	}
+
+	pod := &VAST{Version: "4.1", Ads: []Ad{
+		podAd("a", 1, 15*time.Second),
+		podAd("b", 2, 10500*time.Millisecond),
+		podAd("c", 0, 30*time.Second),
+	}}
+	info = pod.VideoInfo()
+	assert.Equal(t, []int{PROTOCOL_VAST_4}, info.Protocols)
+	assert.Equal(t, 26, info.Dur)
+}
+
+func TestBidSetVAST(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_adaptv_attempt_attr.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	b := &Bid{ID: "1", ImpID: "1", Price: 2}
+	assert.NoError(t, b.SetVAST(v))
+	assert.Equal(t, MTYPE_VIDEO, b.MType)
+	assert.Equal(t, PROTOCOL_VAST_3, b.Protocol)
+	assert.Equal(t, 960, b.W)
+	assert.Equal(t, 15, b.Dur)
+
+	res, err := b.DecodeVAST(nil, DecodeOptions{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, v.Ads[0].ID, res.VAST.Ads[0].ID)
+	}
+}
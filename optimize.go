@@ -0,0 +1,130 @@
+package vast
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OptimizePod fills an ad break of the given duration from the InLine ads of
+// the candidate documents. It picks the ads whose durations sum as close as
+// possible to the break without exceeding it, then the most valuable such
+// set according to InLine.Pricing, with at most one ad per Advertiser.
+//
+// The chosen ads are returned as a pod numbered in candidate order, along with
+// the unused remainder of the break. Wrappers and ads without a linear
+// duration are ignored. The candidates are not modified.
+func OptimizePod(budget Duration, candidates ...*VAST) (*VAST, Duration, error) {
+	if budget <= 0 {
+		return nil, budget, errors.New("empty break duration")
+	}
+
+	// group the candidates by advertiser, ads without one are their own group
+	type candidate struct {
+		ad    *Ad
+		dur   Duration
+		price float64
+	}
+	var ads []candidate
+	var groups [][]int
+	advertisers := map[string]int{}
+	for _, doc := range candidates {
+		if doc == nil {
+			continue
+		}
+		for i := range doc.Ads {
+			ad := &doc.Ads[i]
+			dur := ad.Duration()
+			if ad.InLine == nil || dur == 0 || dur > budget {
+				continue
+			}
+			ads = append(ads, candidate{ad: ad, dur: dur, price: adPrice(ad)})
+			n := len(ads) - 1
+
+			name := strings.ToLower(strings.TrimSpace(ad.InLine.Advertiser))
+			if g, ok := advertisers[name]; ok && name != "" {
+				groups[g] = append(groups[g], n)
+				continue
+			}
+			advertisers[name] = len(groups)
+			groups = append(groups, []int{n})
+		}
+	}
+
+	// group knapsack over the reachable total durations, keeping the best
+	// price for each
+	type state struct {
+		price float64
+		picks []int
+	}
+	states := map[Duration]state{0: {}}
+	for _, group := range groups {
+		next := make(map[Duration]state, len(states))
+		for dur, s := range states {
+			next[dur] = s
+		}
+		for dur, s := range states {
+			for _, n := range group {
+				c := ads[n]
+				total := dur + c.dur
+				if total > budget {
+					continue
+				}
+				price := s.price + c.price
+				picks := append(append(make([]int, 0, len(s.picks)+1), s.picks...), n)
+				// ties go to the earliest candidates so the result is stable
+				if best, ok := next[total]; ok && (best.price > price || best.price == price && !lessInts(picks, best.picks)) {
+					continue
+				}
+				next[total] = state{price: price, picks: picks}
+			}
+		}
+		states = next
+	}
+
+	var filled Duration
+	for dur := range states {
+		if dur > filled {
+			filled = dur
+		}
+	}
+	if filled == 0 {
+		return nil, budget, errors.New("empty ads")
+	}
+
+	picks := states[filled].picks
+	sort.Ints(picks)
+	pod := &VAST{Version: "3.0", Ads: make([]Ad, 0, len(picks))}
+	ids := map[string]int{}
+	for _, n := range picks {
+		ad := *ads[n].ad.Clone()
+		ad.ID = uniqueID(ids, ad.ID)
+		ad.Sequence = len(pod.Ads) + 1
+		pod.Ads = append(pod.Ads, ad)
+	}
+	return pod, budget - filled, nil
+}
+
+// adPrice returns the InLine.Pricing of an ad as a number, zero if it is not
+// one
+func adPrice(ad *Ad) float64 {
+	if ad.InLine == nil {
+		return 0
+	}
+	price, err := strconv.ParseFloat(strings.TrimSpace(ad.InLine.Pricing), 64)
+	if err != nil || price < 0 {
+		return 0
+	}
+	return price
+}
+
+// lessInts compares two slices lexicographically
+func lessInts(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
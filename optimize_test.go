@@ -0,0 +1,64 @@
+package vast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func optimizeAd(id, advertiser, price string, dur time.Duration) *VAST {
+	ad := podAd(id, 0, dur)
+	ad.InLine.Advertiser = advertiser
+	ad.InLine.Pricing = price
+	return &VAST{Ads: []Ad{ad}}
+}
+
+func podIDs(v *VAST) []string {
+	var ids []string
+	for _, ad := range v.Ads {
+		ids = append(ids, ad.ID)
+	}
+	return ids
+}
+
+func TestOptimizePod(t *testing.T) {
+	v, rest, err := OptimizePod(Duration(60*time.Second),
+		optimizeAd("a", "Cola", "10", 30*time.Second),
+		optimizeAd("b", "cola ", "20", 30*time.Second),
+		optimizeAd("c", "Cars", "1", 15*time.Second),
+		optimizeAd("d", "Shoes", "5", 15*time.Second),
+		optimizeAd("e", "", "bad", 45*time.Second),
+		optimizeAd("long", "", "100", 90*time.Second),
+		&VAST{Ads: []Ad{{ID: "wrap", Wrapper: &Wrapper{}}}},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the break is filled by a single cola ad, the most valuable one
+	assert.Equal(t, []string{"b", "c", "d"}, podIDs(v))
+	assert.Equal(t, Duration(0), rest)
+	for i, ad := range v.Ads {
+		assert.Equal(t, i+1, ad.Sequence)
+	}
+	assert.NoError(t, v.ValidatePod())
+}
+
+func TestOptimizePodRemainder(t *testing.T) {
+	v, rest, err := OptimizePod(Duration(40*time.Second),
+		optimizeAd("a", "", "", 15*time.Second),
+		optimizeAd("b", "", "", 30*time.Second),
+		optimizeAd("c", "", "", 20*time.Second),
+	)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"a", "c"}, podIDs(v))
+		assert.Equal(t, Duration(5*time.Second), rest)
+	}
+
+	_, rest, err = OptimizePod(Duration(10*time.Second), optimizeAd("a", "", "", 15*time.Second))
+	assert.EqualError(t, err, "empty ads")
+	assert.Equal(t, Duration(10*time.Second), rest)
+
+	_, _, err = OptimizePod(0)
+	assert.EqualError(t, err, "empty break duration")
+}
@@ -0,0 +1,246 @@
+package vast
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PodOptions tunes MergeAsPod. Zero values mean no limit.
+type PodOptions struct {
+	// Maximum total duration of the sequenced ads
+	MaxDuration Duration
+	// Maximum number of sequenced ads
+	MaxAds int
+	// VAST version of the merged document, the highest version of the merged
+	// documents if empty
+	Version string
+}
+
+// Duration returns the duration of the ad: the longest Linear.Duration of an
+// InLine ad, zero for wrappers and non linear ads.
+func (ad *Ad) Duration() Duration {
+	var dur Duration
+	if ad.InLine != nil {
+		for _, c := range ad.InLine.Creatives {
+			if c.Linear != nil && c.Linear.Duration > dur {
+				dur = c.Linear.Duration
+			}
+		}
+	}
+	return dur
+}
+
+// Pod returns the ads of the pod, the ads with a sequence, in playback order.
+// The pointers are into v.Ads and are invalidated by ReplacePodAd.
+func (v *VAST) Pod() []*Ad {
+	var pod []*Ad
+	for i := range v.Ads {
+		if v.Ads[i].Sequence > 0 {
+			pod = append(pod, &v.Ads[i])
+		}
+	}
+	sort.SliceStable(pod, func(i, j int) bool {
+		return pod[i].Sequence < pod[j].Sequence
+	})
+	return pod
+}
+
+// Buffet returns the stand-alone ads, the ads without a sequence, in document
+// order. They may be played on their own or replace a pod ad which fails.
+func (v *VAST) Buffet() []*Ad {
+	var buffet []*Ad
+	for i := range v.Ads {
+		if v.Ads[i].Sequence == 0 {
+			buffet = append(buffet, &v.Ads[i])
+		}
+	}
+	return buffet
+}
+
+// PodDuration returns the total duration of the pod, see Ad.Duration.
+func (v *VAST) PodDuration() Duration {
+	var total Duration
+	for _, ad := range v.Pod() {
+		total += ad.Duration()
+	}
+	return total
+}
+
+// ValidatePod checks the sequences of the pod are unique and numbered from 1
+// without gaps.
+func (v *VAST) ValidatePod() error {
+	for i, ad := range v.Pod() {
+		if ad.Sequence < i+1 {
+			return fmt.Errorf("duplicate sequence %d", ad.Sequence)
+		}
+		if ad.Sequence > i+1 {
+			return fmt.Errorf("missing sequence %d", i+1)
+		}
+	}
+	return nil
+}
+
+// renumberPod numbers the pod ads from 1 in playback order, closing the gaps
+// left by removed ads
+func (v *VAST) renumberPod() {
+	for i, ad := range v.Pod() {
+		ad.Sequence = i + 1
+	}
+}
+
+// ReplacePodAd substitutes the pod ad with the given sequence, which failed to
+// play, with the first buffet ad no longer than it. The failed ad is removed
+// and the replacement, which takes its sequence, is returned.
+func (v *VAST) ReplacePodAd(seq int) (*Ad, error) {
+	failed := -1
+	for i := range v.Ads {
+		if seq > 0 && v.Ads[i].Sequence == seq {
+			failed = i
+			break
+		}
+	}
+	if failed < 0 {
+		return nil, fmt.Errorf("no ad with sequence %d", seq)
+	}
+
+	limit := v.Ads[failed].Duration()
+	sub, buffet := -1, 0
+	for i := range v.Ads {
+		if v.Ads[i].Sequence != 0 {
+			continue
+		}
+		buffet++
+		if limit == 0 || v.Ads[i].Duration() <= limit {
+			sub = i
+			break
+		}
+	}
+	if sub < 0 {
+		if buffet == 0 {
+			return nil, errors.New("empty buffet")
+		}
+		return nil, fmt.Errorf("no buffet ad fits %s", textOf(limit))
+	}
+
+	ad := v.Ads[sub]
+	ad.Sequence = seq
+	v.Ads[failed] = ad
+	v.Ads = append(v.Ads[:sub], v.Ads[sub+1:]...)
+	if sub < failed {
+		failed--
+	}
+	return &v.Ads[failed], nil
+}
+
+// MergeAsPod combines several responses into a single document holding an ad
+// pod followed by a buffet of stand-alone ads. The documents are not modified.
+//
+// The sequenced ads of each document, in sequence order, or its first ad if
+// none is sequenced, are appended to the pod and numbered from 1. Other ads are
+// kept as the buffet. Pod ads which would exceed opts.MaxAds or
+// opts.MaxDuration are dropped, except a first ad taken from a document without
+// a pod, which joins the buffet. Buffet ads longer than MaxDuration are
+// dropped.
+// Wrappers count as zero duration since theirs is unknown until resolved.
+//
+// Duplicate ad ids are made unique by appending -2, -3, ... and the error
+// pixels of the documents are unioned.
+func MergeAsPod(opts PodOptions, docs ...*VAST) (*VAST, error) {
+	pod := &VAST{Version: opts.Version}
+	var buffet []Ad
+	var total Duration
+	ids := map[string]int{}
+	errs := map[string]bool{}
+
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		if opts.Version == "" && doc.Version > pod.Version {
+			pod.Version = doc.Version
+		}
+		for _, e := range doc.Errors {
+			uri := strings.TrimSpace(e.CDATA)
+			if uri != "" && !errs[uri] {
+				errs[uri] = true
+				pod.Errors = append(pod.Errors, CDATAString{uri})
+			}
+		}
+
+		seq, rest := splitPod(doc.Ads)
+		for _, ad := range seq {
+			dur := ad.Duration()
+			if (opts.MaxAds > 0 && len(pod.Ads) >= opts.MaxAds) ||
+				(opts.MaxDuration > 0 && total+dur > opts.MaxDuration) {
+				// a stand-alone ad promoted to the pod goes back to the buffet
+				if ad.Sequence == 0 {
+					rest = append([]Ad{ad}, rest...)
+				}
+				continue
+			}
+			total += dur
+			ad = *ad.Clone()
+			ad.ID = uniqueID(ids, ad.ID)
+			ad.Sequence = len(pod.Ads) + 1
+			pod.Ads = append(pod.Ads, ad)
+		}
+		for _, ad := range rest {
+			if opts.MaxDuration > 0 && ad.Duration() > opts.MaxDuration {
+				continue
+			}
+			ad = *ad.Clone()
+			ad.ID = uniqueID(ids, ad.ID)
+			buffet = append(buffet, ad)
+		}
+	}
+
+	if len(pod.Ads) == 0 && len(buffet) == 0 {
+		return nil, errors.New("empty ads")
+	}
+	if pod.Version == "" {
+		pod.Version = "3.0"
+	}
+	pod.Ads = append(pod.Ads, buffet...)
+	return pod, nil
+}
+
+// splitPod returns the sequenced ads in sequence order, or the first ad if
+// none is sequenced, and the other ads
+func splitPod(ads []Ad) (seq, rest []Ad) {
+	for _, ad := range ads {
+		if ad.Sequence > 0 {
+			seq = append(seq, ad)
+		} else {
+			rest = append(rest, ad)
+		}
+	}
+	if len(seq) == 0 && len(rest) > 0 {
+		return rest[:1], rest[1:]
+	}
+	sort.SliceStable(seq, func(i, j int) bool {
+		return seq[i].Sequence < seq[j].Sequence
+	})
+	return seq, rest
+}
+
+// uniqueID returns id, suffixed with -2, -3, ... if it was already used
+func uniqueID(used map[string]int, id string) string {
+	if id == "" {
+		return id
+	}
+	for {
+		used[id]++
+		n := used[id]
+		if n == 1 {
+			return id
+		}
+		next := id + "-" + strconv.Itoa(n)
+		if used[next] == 0 {
+			used[next] = 1
+			return next
+		}
+	}
+}
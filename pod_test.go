@@ -0,0 +1,132 @@
+package vast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func podAd(id string, seq int, dur time.Duration) Ad {
+	return Ad{ID: id, Sequence: seq, InLine: &InLine{
+		AdTitle:   CDATAString{id},
+		Creatives: []Creative{{Linear: &Linear{Duration: Duration(dur)}}},
+	}}
+}
+
+func TestAdDuration(t *testing.T) {
+	ad := podAd("a", 0, 15*time.Second)
+	ad.InLine.Creatives = append(ad.InLine.Creatives, Creative{Linear: &Linear{Duration: Duration(30 * time.Second)}}, Creative{})
+	assert.Equal(t, Duration(30*time.Second), ad.Duration())
+
+	wrap := Ad{Wrapper: &Wrapper{}}
+	assert.Equal(t, Duration(0), wrap.Duration())
+}
+
+func TestMergeAsPod(t *testing.T) {
+	a := &VAST{Version: "3.0", Ads: []Ad{podAd("a", 0, 15*time.Second)}, Errors: []CDATAString{{"http://err"}}}
+	b := &VAST{Version: "4.0", Ads: []Ad{
+		podAd("b2", 2, 15*time.Second),
+		podAd("b1", 1, 30*time.Second),
+		podAd("buffet", 0, 10*time.Second),
+	}, Errors: []CDATAString{{" http://err "}, {"http://err2"}}}
+	c := &VAST{Ads: []Ad{podAd("a", 0, 10*time.Second)}}
+
+	v, err := MergeAsPod(PodOptions{}, a, b, nil, c)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "4.0", v.Version)
+	assert.Equal(t, []CDATAString{{"http://err"}, {"http://err2"}}, v.Errors)
+
+	var ids []string
+	var seqs []int
+	for _, ad := range v.Ads {
+		ids = append(ids, ad.ID)
+		seqs = append(seqs, ad.Sequence)
+	}
+	assert.Equal(t, []string{"a", "b1", "b2", "a-2", "buffet"}, ids)
+	assert.Equal(t, []int{1, 2, 3, 4, 0}, seqs)
+
+	// inputs are not modified
+	assert.Equal(t, 2, b.Ads[0].Sequence)
+	assert.Equal(t, "a", c.Ads[0].ID)
+}
+
+func TestMergeAsPodLimits(t *testing.T) {
+	a := &VAST{Ads: []Ad{podAd("a", 0, 15*time.Second)}}
+	b := &VAST{Ads: []Ad{podAd("b", 0, 30*time.Second), podAd("long", 0, time.Minute)}}
+	c := &VAST{Ads: []Ad{podAd("c", 0, 10*time.Second)}}
+	d := &VAST{Ads: []Ad{podAd("d", 0, 5*time.Second)}}
+
+	v, err := MergeAsPod(PodOptions{MaxDuration: Duration(30 * time.Second), MaxAds: 2, Version: "3.0"}, a, b, c, d)
+	if assert.NoError(t, err) && assert.Len(t, v.Ads, 4) {
+		// b does not fit the remaining 15s, d exceeds the ad count: both join
+		// the buffet, long is dropped
+		assert.Equal(t, "a", v.Ads[0].ID)
+		assert.Equal(t, "c", v.Ads[1].ID)
+		assert.Equal(t, 2, v.Ads[1].Sequence)
+		assert.Equal(t, "b", v.Ads[2].ID)
+		assert.Equal(t, 0, v.Ads[2].Sequence)
+		assert.Equal(t, "d", v.Ads[3].ID)
+		assert.Equal(t, 0, v.Ads[3].Sequence)
+	}
+
+	// sequenced ads which do not fit are dropped
+	e := &VAST{Ads: []Ad{podAd("e1", 1, 20*time.Second), podAd("e2", 2, 20*time.Second)}}
+	v, err = MergeAsPod(PodOptions{MaxDuration: Duration(30 * time.Second)}, e)
+	if assert.NoError(t, err) && assert.Len(t, v.Ads, 1) {
+		assert.Equal(t, "e1", v.Ads[0].ID)
+	}
+
+	_, err = MergeAsPod(PodOptions{}, &VAST{}, nil)
+	assert.EqualError(t, err, "empty ads")
+}
+
+func TestPod(t *testing.T) {
+	v := &VAST{Ads: []Ad{
+		podAd("b", 2, 15*time.Second),
+		podAd("buffet-long", 0, time.Minute),
+		podAd("a", 1, 30*time.Second),
+		podAd("buffet", 0, 10*time.Second),
+	}}
+
+	pod := v.Pod()
+	if assert.Len(t, pod, 2) {
+		assert.Equal(t, "a", pod[0].ID)
+		assert.Equal(t, "b", pod[1].ID)
+	}
+	buffet := v.Buffet()
+	if assert.Len(t, buffet, 2) {
+		assert.Equal(t, "buffet-long", buffet[0].ID)
+	}
+	assert.Equal(t, Duration(45*time.Second), v.PodDuration())
+	assert.NoError(t, v.ValidatePod())
+
+	ad, err := v.ReplacePodAd(2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "buffet", ad.ID)
+		assert.Equal(t, 2, ad.Sequence)
+	}
+	assert.Len(t, v.Ads, 3)
+	assert.Equal(t, "buffet", v.Pod()[1].ID)
+	assert.NoError(t, v.ValidatePod())
+
+	_, err = v.ReplacePodAd(1)
+	assert.EqualError(t, err, "no buffet ad fits 00:00:30")
+	_, err = v.ReplacePodAd(3)
+	assert.EqualError(t, err, "no ad with sequence 3")
+	_, err = (&VAST{Ads: []Ad{podAd("a", 1, 0)}}).ReplacePodAd(1)
+	assert.EqualError(t, err, "empty buffet")
+}
+
+func TestValidatePod(t *testing.T) {
+	v := &VAST{Ads: []Ad{podAd("a", 1, 0), podAd("b", 1, 0)}}
+	assert.EqualError(t, v.ValidatePod(), "duplicate sequence 1")
+
+	v = &VAST{Ads: []Ad{podAd("a", 1, 0), podAd("b", 3, 0)}}
+	assert.EqualError(t, v.ValidatePod(), "missing sequence 2")
+
+	v = &VAST{Ads: []Ad{podAd("a", 2, 0)}}
+	assert.EqualError(t, v.ValidatePod(), "missing sequence 1")
+}
@@ -0,0 +1,188 @@
+package vast
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Position is the location of an element in the decoded document.
+type Position struct {
+	// Byte offset of the start tag
+	Offset int64
+	// 1-based line and column of the start tag
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// positioned elements recorded in a SourceMap
+var positionedElements = map[string]bool{
+	"Ad":        true,
+	"Creative":  true,
+	"MediaFile": true,
+	"Tracking":  true,
+	"Extension": true,
+}
+
+// repeatable elements are indexed in element paths, e.g. Ad[0]/InLine
+var indexedElements = map[string]bool{
+	"Ad":                     true,
+	"Creative":               true,
+	"MediaFile":              true,
+	"Tracking":               true,
+	"Extension":              true,
+	"Impression":             true,
+	"Viewable":               true,
+	"Error":                  true,
+	"ClickThrough":           true,
+	"ClickTracking":          true,
+	"CustomClick":            true,
+	"Companion":              true,
+	"CompanionClickTracking": true,
+	"NonLinear":              true,
+	"NonLinearClickTracking": true,
+	"Icon":                   true,
+	"IconClickTracking":      true,
+	"IconViewTracking":       true,
+}
+
+// pathElem returns the path segment of the i-th child element with the given name
+func pathElem(name string, i int) string {
+	if indexedElements[name] {
+		return name + "[" + strconv.Itoa(i) + "]"
+	}
+	return name
+}
+
+// joinPath appends path segments
+func joinPath(path string, elems ...string) string {
+	for _, e := range elems {
+		if path == "" {
+			path = e
+		} else {
+			path += "/" + e
+		}
+	}
+	return path
+}
+
+// SourceMap records the source position of the Ad, Creative, MediaFile,
+// Tracking and Extension elements of a decoded document. It is filled by Decode
+// when DecodeOptions.Positions is set.
+//
+// Elements are looked up by pointer into the decoded VAST, so positions are
+// only reliable until the slices holding them are modified.
+type SourceMap struct {
+	paths map[string]Position
+	elems map[interface{}]string
+}
+
+func newSourceMap() *SourceMap {
+	return &SourceMap{
+		paths: make(map[string]Position),
+		elems: make(map[interface{}]string),
+	}
+}
+
+// Lookup returns the position of an element given as a pointer into the
+// decoded document (*Ad, *Creative, *CreativeWrapper, *MediaFile, *Tracking or
+// *Extension).
+func (m *SourceMap) Lookup(elem interface{}) (Position, bool) {
+	path, ok := m.Path(elem)
+	if !ok {
+		return Position{}, false
+	}
+	return m.PathPosition(path)
+}
+
+// Path returns the element path, e.g. Ad[0]/InLine/Creatives/Creative[1], of
+// an element given as a pointer into the decoded document.
+func (m *SourceMap) Path(elem interface{}) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	path, ok := m.elems[elem]
+	return path, ok
+}
+
+// PathPosition returns the position of the element at the given path.
+func (m *SourceMap) PathPosition(path string) (Position, bool) {
+	if m == nil {
+		return Position{}, false
+	}
+	p, ok := m.paths[path]
+	return p, ok
+}
+
+// bind maps the elements of the decoded document to their path
+func (m *SourceMap) bind(v *VAST) {
+	for i := range v.Ads {
+		ad := &v.Ads[i]
+		path := pathElem("Ad", i)
+		m.elems[ad] = path
+
+		if ad.InLine != nil {
+			path := joinPath(path, "InLine")
+			for i := range ad.InLine.Creatives {
+				c := &ad.InLine.Creatives[i]
+				path := joinPath(path, "Creatives", pathElem("Creative", i))
+				m.elems[c] = path
+				if c.Linear != nil {
+					m.bindTrackings(c.Linear.TrackingEvents, joinPath(path, "Linear", "TrackingEvents"))
+					for i := range c.Linear.MediaFiles {
+						m.elems[&c.Linear.MediaFiles[i]] = joinPath(path, "Linear", "MediaFiles", pathElem("MediaFile", i))
+					}
+				}
+				if c.NonLinearAds != nil {
+					m.bindTrackings(c.NonLinearAds.TrackingEvents, joinPath(path, "NonLinearAds", "TrackingEvents"))
+				}
+				if c.CompanionAds != nil {
+					for i, comp := range c.CompanionAds.Companions {
+						m.bindTrackings(comp.TrackingEvents, joinPath(path, "CompanionAds", pathElem("Companion", i), "TrackingEvents"))
+					}
+				}
+			}
+			m.bindExtensions(ad.InLine.Extensions, joinPath(path, "Extensions"))
+		} else if ad.Wrapper != nil {
+			path := joinPath(path, "Wrapper")
+			for i := range ad.Wrapper.Creatives {
+				c := &ad.Wrapper.Creatives[i]
+				path := joinPath(path, "Creatives", pathElem("Creative", i))
+				m.elems[c] = path
+				if c.Linear != nil {
+					m.bindTrackings(c.Linear.TrackingEvents, joinPath(path, "Linear", "TrackingEvents"))
+				}
+				if c.NonLinearAds != nil {
+					m.bindTrackings(c.NonLinearAds.TrackingEvents, joinPath(path, "NonLinearAds", "TrackingEvents"))
+					for i, nl := range c.NonLinearAds.NonLinears {
+						m.bindTrackings(nl.TrackingEvents, joinPath(path, "NonLinearAds", pathElem("NonLinear", i), "TrackingEvents"))
+					}
+				}
+				if c.CompanionAds != nil {
+					for i, comp := range c.CompanionAds.Companions {
+						m.bindTrackings(comp.TrackingEvents, joinPath(path, "CompanionAds", pathElem("Companion", i), "TrackingEvents"))
+					}
+				}
+			}
+			m.bindExtensions(ad.Wrapper.Extensions, joinPath(path, "Extensions"))
+		}
+	}
+}
+
+func (m *SourceMap) bindTrackings(trackings []Tracking, path string) {
+	for i := range trackings {
+		m.elems[&trackings[i]] = joinPath(path, pathElem("Tracking", i))
+	}
+}
+
+func (m *SourceMap) bindExtensions(exts []Extension, path string) {
+	for i := range exts {
+		e := &exts[i]
+		path := joinPath(path, pathElem("Extension", i))
+		m.elems[e] = path
+		m.bindTrackings(e.CustomTracking, joinPath(path, "CustomTracking"))
+	}
+}
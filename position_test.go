@@ -0,0 +1,105 @@
+package vast
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodePositions(t *testing.T) {
+	f, err := os.Open("testdata/inline_extensions.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	res, err := Decode(f, DecodeOptions{Positions: true})
+	if !assert.NoError(t, err) || !assert.NotNil(t, res.Positions) {
+		return
+	}
+
+	ad := &res.VAST.Ads[0]
+	p, ok := res.Positions.Lookup(ad)
+	if assert.True(t, ok) {
+		assert.Equal(t, Position{Offset: 157, Line: 3, Column: 3}, p)
+	}
+
+	ext := &ad.InLine.Extensions[1]
+	path, ok := res.Positions.Path(ext)
+	if assert.True(t, ok) {
+		assert.Equal(t, "Ad[0]/InLine/Extensions/Extension[1]", path)
+	}
+	p, ok = res.Positions.Lookup(ext)
+	if assert.True(t, ok) {
+		assert.Equal(t, "11:9", p.String())
+	}
+
+	p, ok = res.Positions.Lookup(&ext.CustomTracking[1])
+	if assert.True(t, ok) {
+		assert.Equal(t, 14, p.Line)
+	}
+
+	_, ok = res.Positions.Lookup(&Ad{})
+	assert.False(t, ok)
+}
+
+func TestDecodePositionsCreatives(t *testing.T) {
+	f, err := os.Open("testdata/vast_inline_linear.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	res, err := Decode(f, DecodeOptions{Positions: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	inline := res.VAST.Ads[0].InLine
+	linear := inline.Creatives[0].Linear
+	path, _ := res.Positions.Path(&linear.MediaFiles[0])
+	assert.Equal(t, "Ad[0]/InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[0]", path)
+	path, _ = res.Positions.Path(&linear.TrackingEvents[2])
+	assert.Equal(t, "Ad[0]/InLine/Creatives/Creative[0]/Linear/TrackingEvents/Tracking[2]", path)
+
+	for _, elem := range []interface{}{&inline.Creatives[1], &linear.MediaFiles[0], &linear.TrackingEvents[2]} {
+		p, ok := res.Positions.Lookup(elem)
+		if assert.True(t, ok) {
+			assert.True(t, p.Line > 1)
+		}
+	}
+}
+
+func TestDecodeWithoutPositions(t *testing.T) {
+	res, err := Decode(strings.NewReader(malformedVAST), DecodeOptions{})
+	if assert.NoError(t, err) {
+		assert.Nil(t, res.Positions)
+		_, ok := res.Positions.Lookup(&res.VAST.Ads[0])
+		assert.False(t, ok)
+	}
+}
+
+func TestDecodePositionsLeadingJunk(t *testing.T) {
+	for _, prefix := range []string{"\n\n\n", "\n junk\n\n", "\n\n\n<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>"} {
+		doc := prefix + "<VAST version=\"3.0\">\n" +
+			"  <Ad id=\"1\"><InLine><Creatives><Creative>\n" +
+			"    <Linear><Duration>00:30</Duration></Linear>\n" +
+			"  </Creative></Creatives></InLine></Ad>\n" +
+			"</VAST>"
+		res, err := Decode(strings.NewReader(doc), DecodeOptions{Positions: true})
+		if !assert.NoError(t, err, prefix) {
+			continue
+		}
+		p, ok := res.Positions.Lookup(&res.VAST.Ads[0])
+		if assert.True(t, ok, prefix) {
+			// offsets are into the converted document, declared as UTF-8
+			converted := strings.Replace(doc, "ISO-8859-1", "UTF-8", 1)
+			assert.Equal(t, Position{Offset: int64(strings.Index(converted, "<Ad ")), Line: 5, Column: 3}, p, prefix)
+		}
+		if assert.Len(t, res.Warnings, 1, prefix) {
+			assert.Equal(t, "6:13", Position{Line: res.Warnings[0].Line, Column: res.Warnings[0].Column}.String(), prefix)
+		}
+	}
+}
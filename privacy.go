@@ -0,0 +1,156 @@
+package vast
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Consent holds the privacy signals of the request.
+type Consent struct {
+	// GDPR applies to the request
+	GDPR bool
+	// TCF consent string
+	ConsentString string
+	// Consented TCF vendor ids
+	Vendors []int
+	// IAB US privacy string, e.g. 1YYN
+	USPrivacy string
+	// The user limited ad tracking
+	LimitAdTracking bool
+	// COPPA applies to the request
+	COPPA bool
+}
+
+// optOut reports whether the US privacy string opts out of sale
+func (c Consent) optOut() bool {
+	return len(c.USPrivacy) >= 3 && (c.USPrivacy[2] == 'Y' || c.USPrivacy[2] == 'y')
+}
+
+// consented reports whether the pixels of a vendor may be fired
+func (c Consent) consented(vendor int) bool {
+	if c.LimitAdTracking || c.optOut() {
+		return false
+	}
+	if !c.GDPR {
+		return true
+	}
+	for _, id := range c.Vendors {
+		if id == vendor {
+			return true
+		}
+	}
+	return false
+}
+
+// restricted reports whether pixels may only fire with the consent of their
+// vendor: GDPR applies without a consent string, ad tracking is limited or the
+// US privacy string opts out of sale
+func (c Consent) restricted() bool {
+	return (c.GDPR && c.ConsentString == "") || c.LimitAdTracking || c.optOut()
+}
+
+// regulations returns the value of the [REGULATIONS] macro
+func (c Consent) regulations() string {
+	var regs []string
+	if c.COPPA {
+		regs = append(regs, "coppa")
+	}
+	if c.GDPR {
+		regs = append(regs, "gdpr")
+	}
+	return strings.Join(regs, ",")
+}
+
+// SuppressedURI is a pixel removed by ApplyConsent.
+type SuppressedURI struct {
+	// Element path of the pixel before it was removed, see EachURI
+	Path string
+	URI  string
+	// Zero if the host of the pixel can't be found
+	Vendor int
+}
+
+// pixel elements subject to consent
+var consentElements = map[string]bool{
+	"Impression":             true,
+	"Viewable":               true,
+	"Tracking":               true,
+	"ClickTracking":          true,
+	"CompanionClickTracking": true,
+	"NonLinearClickTracking": true,
+	"IconClickTracking":      true,
+	"IconViewTracking":       true,
+}
+
+// ApplyConsent expands the [GDPRCONSENT], [LIMITADTRACKING] and [REGULATIONS]
+// macros in every URI of the document, then removes the impression, tracking
+// and click tracking pixels of the vendors without consent.
+//
+// vendors maps TCF vendor ids to the host patterns of their pixels (see
+// DomainPolicy). A vendor has consent unless ad tracking is limited, the US
+// privacy string opts out of sale, or GDPR applies and the vendor is not in
+// c.Vendors. Pixels of hosts which belong to no vendor are kept. Pixels whose
+// host can't be found are removed, with a zero vendor, when GDPR applies
+// without a consent string, ad tracking is limited or the US privacy string
+// opts out of sale.
+func (v *VAST) ApplyConsent(c Consent, vendors map[int][]string) []SuppressedURI {
+	lmt := "0"
+	if c.LimitAdTracking {
+		lmt = "1"
+	}
+	macros := strings.NewReplacer(
+		"[GDPRCONSENT]", url.QueryEscape(c.ConsentString),
+		"[LIMITADTRACKING]", lmt,
+		"[REGULATIONS]", url.QueryEscape(c.regulations()),
+	)
+
+	ids := make([]int, 0, len(vendors))
+	for id := range vendors {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var suppressed []SuppressedURI
+	v.EachURI(func(path string, uri *string) {
+		*uri = macros.Replace(*uri)
+
+		if !consentElements[pathName(path)] {
+			return
+		}
+		host := uriHost(*uri)
+		if host == "" {
+			// the vendor can't be told, drop the pixel unless tracking is allowed
+			if c.restricted() {
+				suppressed = append(suppressed, SuppressedURI{Path: path, URI: *uri})
+				*uri = ""
+			}
+			return
+		}
+		for _, id := range ids {
+			if matchHostPatterns(host, vendors[id]) {
+				if !c.consented(id) {
+					suppressed = append(suppressed, SuppressedURI{Path: path, URI: *uri, Vendor: id})
+					*uri = ""
+				}
+				return
+			}
+		}
+	})
+
+	if len(suppressed) > 0 {
+		v.dropEmptyURIs()
+	}
+	return suppressed
+}
+
+// pathName returns the element name of the last segment of a path
+func pathName(path string) string {
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		path = path[i+1:]
+	}
+	if i := strings.IndexByte(path, '['); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
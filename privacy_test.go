@@ -0,0 +1,88 @@
+package vast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func consentVAST() *VAST {
+	return &VAST{Ads: []Ad{{InLine: &InLine{
+		Impressions: []Impression{
+			{URI: "https://us.com/imp?gdpr_consent=[GDPRCONSENT]&lmt=[LIMITADTRACKING]&regs=[REGULATIONS]"},
+			{URI: "https://pixel.vendor-a.com/imp"},
+			{URI: "https://vendor-b.com/imp"},
+		},
+		Creatives: []Creative{{Linear: &Linear{
+			TrackingEvents: []Tracking{{Event: TRACK_START, URI: "https://pixel.vendor-a.com/start"}},
+			VideoClicks: &VideoClicks{
+				ClickThroughs:  []VideoClick{{URI: "https://vendor-a.com/landing"}},
+				ClickTrackings: []VideoClick{{URI: "https://vendor-b.com/click"}},
+			},
+			MediaFiles: []MediaFile{{URI: "https://cdn.vendor-a.com/ad.mp4", Type: "video/mp4"}},
+		}}},
+	}}}}
+}
+
+var consentVendors = map[int][]string{
+	1: {"*.vendor-a.com", "vendor-a.com"},
+	2: {"vendor-b.com"},
+}
+
+func TestApplyConsentGDPR(t *testing.T) {
+	v := consentVAST()
+	suppressed := v.ApplyConsent(Consent{GDPR: true, ConsentString: "CO+x/y", Vendors: []int{2}, COPPA: true}, consentVendors)
+	assert.Equal(t, []SuppressedURI{
+		{Path: "Ad[0]/InLine/Impression[1]", URI: "https://pixel.vendor-a.com/imp", Vendor: 1},
+		{Path: "Ad[0]/InLine/Creatives/Creative[0]/Linear/TrackingEvents/Tracking[0]", URI: "https://pixel.vendor-a.com/start", Vendor: 1},
+	}, suppressed)
+
+	inline := v.Ads[0].InLine
+	assert.Equal(t, []Impression{
+		{URI: "https://us.com/imp?gdpr_consent=CO%2Bx%2Fy&lmt=0&regs=coppa%2Cgdpr"},
+		{URI: "https://vendor-b.com/imp"},
+	}, inline.Impressions)
+	l := inline.Creatives[0].Linear
+	assert.Empty(t, l.TrackingEvents)
+	// click throughs and media are not pixels
+	assert.Len(t, l.VideoClicks.ClickThroughs, 1)
+	assert.Len(t, l.VideoClicks.ClickTrackings, 1)
+	assert.Len(t, l.MediaFiles, 1)
+}
+
+func TestApplyConsentOptOut(t *testing.T) {
+	v := consentVAST()
+	assert.Empty(t, v.ApplyConsent(Consent{USPrivacy: "1YNN"}, consentVendors))
+	assert.Equal(t, "https://us.com/imp?gdpr_consent=&lmt=0&regs=", v.Ads[0].InLine.Impressions[0].URI)
+
+	v = consentVAST()
+	assert.Len(t, v.ApplyConsent(Consent{USPrivacy: "1YYN"}, consentVendors), 4)
+	assert.Len(t, v.Ads[0].InLine.Impressions, 1)
+	assert.Nil(t, v.Ads[0].InLine.Creatives[0].Linear.VideoClicks.ClickTrackings)
+
+	v = consentVAST()
+	assert.Len(t, v.ApplyConsent(Consent{LimitAdTracking: true}, consentVendors), 4)
+	assert.Equal(t, "https://us.com/imp?gdpr_consent=&lmt=1&regs=", v.Ads[0].InLine.Impressions[0].URI)
+}
+
+func TestApplyConsentUnparsable(t *testing.T) {
+	v := consentVAST()
+	v.Ads[0].InLine.Impressions = append(v.Ads[0].InLine.Impressions,
+		Impression{URI: "https://vendor-b.com/p%zz"},
+		Impression{URI: "[PROTOCOL]://pixel.vendor-a.com/imp"},
+		Impression{URI: "pixel %zz"},
+	)
+
+	// GDPR without consent
+	suppressed := v.ApplyConsent(Consent{GDPR: true}, consentVendors)
+	assert.Contains(t, suppressed, SuppressedURI{Path: "Ad[0]/InLine/Impression[3]", URI: "https://vendor-b.com/p%zz", Vendor: 2})
+	assert.Contains(t, suppressed, SuppressedURI{Path: "Ad[0]/InLine/Impression[4]", URI: "[PROTOCOL]://pixel.vendor-a.com/imp", Vendor: 1})
+	assert.Contains(t, suppressed, SuppressedURI{Path: "Ad[0]/InLine/Impression[5]", URI: "pixel %zz"})
+	assert.Len(t, v.Ads[0].InLine.Impressions, 1)
+
+	// unknown hosts are kept when tracking is allowed
+	v = consentVAST()
+	v.Ads[0].InLine.Impressions = append(v.Ads[0].InLine.Impressions, Impression{URI: "pixel %zz"})
+	assert.Empty(t, v.ApplyConsent(Consent{GDPR: true, ConsentString: "CO", Vendors: []int{1, 2}}, consentVendors))
+	assert.Len(t, v.Ads[0].InLine.Impressions, 4)
+}
@@ -0,0 +1,100 @@
+package vast
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var errNotHTTP = errors.New("not an http(s) URL")
+
+// URLError reports a URI of the document that could not be rewritten.
+type URLError struct {
+	// Element path of the URI, see EachURI
+	Path string
+	URI  string
+	Err  error
+}
+
+func (e *URLError) Error() string {
+	return fmt.Sprintf("%s: %q: %s", e.Path, e.URI, e.Err)
+}
+
+// InsecureURI is a plain http URI found by MixedContent.
+type InsecureURI struct {
+	Path string
+	URI  string
+}
+
+// UpgradeURL sets the scheme of an http, https or protocol relative URL to
+// https, or to http if secure is false. Only the scheme is rewritten: the rest
+// of the URL, including its query encoding, is kept as is. Surrounding
+// whitespace is trimmed.
+//
+// URIs which are not http URLs or cannot be parsed are returned unchanged along
+// with an error.
+func UpgradeURL(uri string, secure bool) (string, error) {
+	s := strings.TrimSpace(uri)
+	if s == "" {
+		return uri, nil
+	}
+
+	var rest string
+	lower := strings.ToLower(s)
+	for _, prefix := range []string{"https://", "http://", "//", "://"} {
+		if strings.HasPrefix(lower, prefix) {
+			rest = s[len(prefix):]
+			break
+		}
+	}
+	if rest == "" {
+		return uri, errNotHTTP
+	}
+
+	scheme := "http://"
+	if secure {
+		scheme = "https://"
+	}
+	if _, err := url.Parse(scheme + rest); err != nil {
+		return uri, err
+	}
+	return scheme + rest, nil
+}
+
+// SecureUrl is UpgradeURL ignoring errors: URIs that cannot be rewritten are
+// returned unchanged.
+func SecureUrl(uri string, secure bool) string {
+	u, err := UpgradeURL(uri, secure)
+	if err != nil {
+		return uri
+	}
+	return u
+}
+
+// UpgradeURLs rewrites every URI of the document with UpgradeURL, returning
+// the URIs left untouched because they could not be rewritten.
+func (v *VAST) UpgradeURLs(secure bool) []*URLError {
+	var errs []*URLError
+	v.EachURI(func(path string, uri *string) {
+		u, err := UpgradeURL(*uri, secure)
+		if err != nil {
+			errs = append(errs, &URLError{Path: path, URI: *uri, Err: err})
+			return
+		}
+		*uri = u
+	})
+	return errs
+}
+
+// MixedContent lists every plain http URI of the document, which a player
+// served over https would block.
+func (v *VAST) MixedContent() []InsecureURI {
+	var insecure []InsecureURI
+	v.EachURI(func(path string, uri *string) {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(*uri)), "http://") {
+			insecure = append(insecure, InsecureURI{Path: path, URI: *uri})
+		}
+	})
+	return insecure
+}
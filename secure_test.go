@@ -0,0 +1,99 @@
+package vast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpgradeURL(t *testing.T) {
+	tests := []struct {
+		uri, want string
+		err       bool
+	}{
+		{"", "", false},
+		{"http://a", "https://a", false},
+		{"HTTP://a.com/x", "https://a.com/x", false},
+		{" //a.com/p?q=a%20b+c&r=%2F ", "https://a.com/p?q=a%20b+c&r=%2F", false},
+		{"://a.com", "https://a.com", false},
+		{"https://a.com/[TIMESTAMP]", "https://a.com/[TIMESTAMP]", false},
+		{"a", "a", true},
+		{"ftp://a.com", "ftp://a.com", true},
+		{"http://[::1", "http://[::1", true},
+	}
+	for _, tt := range tests {
+		u, err := UpgradeURL(tt.uri, true)
+		assert.Equal(t, tt.want, u, tt.uri)
+		assert.Equal(t, tt.err, err != nil, tt.uri)
+	}
+
+	u, err := UpgradeURL("https://a.com/x?y=1", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://a.com/x?y=1", u)
+}
+
+func TestSecureUrl(t *testing.T) {
+	assert.NotPanics(t, func() {
+		assert.Equal(t, "a", SecureUrl("a", true))
+		assert.Equal(t, "", SecureUrl("", true))
+	})
+	assert.Equal(t, "https://a.com/x?y=a b", SecureUrl("http://a.com/x?y=a b", true))
+	assert.Equal(t, "http://[::1", SecureUrl("http://[::1", true))
+}
+
+func TestUpgradeURLs(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_inline_linear.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	insecure := v.MixedContent()
+	if assert.NotEmpty(t, insecure) {
+		assert.Equal(t, InsecureURI{Path: "Ad[0]/InLine/Impression[0]", URI: "http://myTrackingURL/impression"}, insecure[0])
+	}
+
+	v.Ads[0].InLine.Errors = []CDATAString{{"[ERROR_URL]"}}
+	errs := v.UpgradeURLs(true)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "Ad[0]/InLine/Error[0]", errs[0].Path)
+		assert.EqualError(t, errs[0], `Ad[0]/InLine/Error[0]: "[ERROR_URL]": not an http(s) URL`)
+	}
+	assert.Equal(t, "[ERROR_URL]", v.Ads[0].InLine.Errors[0].CDATA)
+	assert.Empty(t, v.MixedContent())
+
+	v.SetSecure(false)
+	assert.Equal(t, "http://myTrackingURL/impression", v.Ads[0].InLine.Impressions[0].URI)
+}
+
+func TestSetSecureLevels(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_inline_linear.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the same URIs are upgraded whatever the level SetSecure is called at
+	inline := v.Ads[0].InLine
+	inline.SetSecure(true)
+	assert.Empty(t, v.MixedContent())
+
+	v.SetSecure(false)
+	for i := range inline.Creatives {
+		inline.Creatives[i].SetSecure(true)
+	}
+	for _, uri := range v.MixedContent() {
+		assert.NotContains(t, uri.Path, "/Creatives/")
+	}
+
+	w := &Wrapper{
+		VASTAdTagURI: CDATAString{"http://tag"},
+		Creatives: []CreativeWrapper{{Linear: &LinearWrapper{Icons: &Icons{Icon: []Icon{{
+			StaticResource:     &StaticResource{URI: "http://icon"},
+			IconClickTrackings: []CDATAString{{"http://icon/click"}},
+		}}}}}},
+	}
+	w.SetSecure(true)
+	assert.Equal(t, "https://tag", w.VASTAdTagURI.CDATA)
+	icon := w.Creatives[0].Linear.Icons.Icon[0]
+	assert.Equal(t, "https://icon", icon.StaticResource.URI)
+	assert.Equal(t, "https://icon/click", icon.IconClickTrackings[0].CDATA)
+}
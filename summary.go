@@ -0,0 +1,229 @@
+package vast
+
+import (
+	"sort"
+)
+
+// Summary is the tracker inventory of a document returned by Summarize. It is
+// meant to be logged as JSON.
+type Summary struct {
+	Version string `json:"version,omitempty"`
+	// Error pixels of a no ad response
+	ErrorPixels int `json:"error_pixels"`
+	// Total duration of the pod, see VAST.PodDuration
+	PodDuration Duration    `json:"pod_duration"`
+	Ads         []AdSummary `json:"ads"`
+}
+
+// AdSummary is the tracker inventory of an ad.
+type AdSummary struct {
+	ID       string `json:"id,omitempty"`
+	Sequence int    `json:"sequence,omitempty"`
+	// "inline" or "wrapper"
+	Type       string `json:"type"`
+	AdSystem   string `json:"ad_system,omitempty"`
+	Advertiser string `json:"advertiser,omitempty"`
+	// Wrappers between the response and this ad: 0 for InLine ads, 1 for
+	// wrappers. Callers resolving wrapper chains may add their own depth.
+	WrapperDepth int `json:"wrapper_depth"`
+	// Duration of the ad, see Ad.Duration
+	Duration Duration `json:"duration"`
+
+	Impressions int `json:"impressions"`
+	Viewables   int `json:"viewables"`
+	ErrorPixels int `json:"error_pixels"`
+	// Trackers per event
+	Trackers map[string]int `json:"trackers,omitempty"`
+	// Trackers of the start, firstQuartile, midpoint, thirdQuartile and complete
+	// events
+	QuartileTrackers int `json:"quartile_trackers"`
+	ClickTrackers    int `json:"click_trackers"`
+	// Open Measurement verification scripts, see AdVerifications
+	Verifications int `json:"verifications"`
+
+	MediaFiles int `json:"media_files"`
+	// Distinct MIME types of the media files
+	MediaFormats []string `json:"media_formats,omitempty"`
+	// Distinct hosts of the URIs of the ad, including verification scripts
+	Domains []string `json:"domains,omitempty"`
+}
+
+var quartileEvents = map[string]bool{
+	TRACK_START:          true,
+	TRACK_FIRST_QUARTILE: true,
+	TRACK_MIDPOINT:       true,
+	TRACK_THIRD_QUARTILE: true,
+	TRACK_COMPLETE:       true,
+}
+
+// Summarize returns the tracker inventory of the document: per ad, the number
+// of impression, tracking, click tracking and error pixels, verification
+// scripts and media files, and the media formats and domains it uses.
+func (v *VAST) Summarize() *Summary {
+	s := &Summary{
+		Version:     v.Version,
+		ErrorPixels: countURIs(cdataURIs(v.Errors)),
+		PodDuration: v.PodDuration(),
+		Ads:         make([]AdSummary, 0, len(v.Ads)),
+	}
+	for i := range v.Ads {
+		s.Ads = append(s.Ads, v.Ads[i].Summarize())
+	}
+	return s
+}
+
+// Summarize returns the tracker inventory of the ad, see VAST.Summarize.
+func (ad *Ad) Summarize() AdSummary {
+	s := AdSummary{ID: ad.ID, Sequence: ad.Sequence, Duration: ad.Duration()}
+
+	var extensions []Extension
+	formats := map[string]bool{}
+	if inline := ad.InLine; inline != nil {
+		s.Type = "inline"
+		s.AdSystem = describeAdSystem(inline.AdSystem)
+		s.Advertiser = inline.Advertiser
+		extensions = inline.Extensions
+		for _, c := range inline.Creatives {
+			if c.Linear != nil {
+				for _, m := range c.Linear.MediaFiles {
+					if m.URI == "" {
+						continue
+					}
+					s.MediaFiles++
+					if m.Type != "" {
+						formats[m.Type] = true
+					}
+				}
+			}
+		}
+	} else if wrap := ad.Wrapper; wrap != nil {
+		s.Type = "wrapper"
+		s.AdSystem = describeAdSystem(wrap.AdSystem)
+		s.WrapperDepth = 1
+		extensions = wrap.Extensions
+	}
+	for format := range formats {
+		s.MediaFormats = append(s.MediaFormats, format)
+	}
+	sort.Strings(s.MediaFormats)
+
+	ad.eachTracking(func(t *Tracking) {
+		if t.URI == "" {
+			return
+		}
+		if s.Trackers == nil {
+			s.Trackers = map[string]int{}
+		}
+		s.Trackers[t.Event]++
+		if quartileEvents[t.Event] {
+			s.QuartileTrackers++
+		}
+	})
+
+	ad.EachURI(func(path string, _ *string) {
+		switch pathName(path) {
+		case "Impression":
+			s.Impressions++
+		case "Viewable":
+			s.Viewables++
+		case "Error":
+			s.ErrorPixels++
+		case "ClickTracking", "CompanionClickTracking", "NonLinearClickTracking", "IconClickTracking":
+			s.ClickTrackers++
+		}
+	})
+
+	// verification scripts are held in extension payloads, not visited by
+	// EachURI
+	domains := ad.ThirdPartyDomains()
+	for _, e := range extensions {
+		if e.Type != EXT_AD_VERIFICATIONS {
+			continue
+		}
+		decoded, err := e.Decode()
+		if err != nil {
+			continue
+		}
+		if av, ok := decoded.(*AdVerifications); ok {
+			for _, ver := range av.Verifications {
+				for _, js := range ver.JavaScriptResources {
+					if host := uriHost(js.URI); host != "" {
+						s.Verifications++
+						domains = appendHost(domains, host)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(domains)
+	s.Domains = domains
+
+	return s
+}
+
+// eachTracking calls fn for every Tracking of the ad: creative, companion and
+// extension trackers
+func (ad *Ad) eachTracking(fn func(t *Tracking)) {
+	each := func(trackings []Tracking) {
+		for i := range trackings {
+			fn(&trackings[i])
+		}
+	}
+	var extensions []Extension
+	if inline := ad.InLine; inline != nil {
+		for _, c := range inline.Creatives {
+			if c.Linear != nil {
+				each(c.Linear.TrackingEvents)
+			}
+			if c.NonLinearAds != nil {
+				each(c.NonLinearAds.TrackingEvents)
+			}
+			if c.CompanionAds != nil {
+				for _, comp := range c.CompanionAds.Companions {
+					each(comp.TrackingEvents)
+				}
+			}
+		}
+		extensions = inline.Extensions
+	} else if wrap := ad.Wrapper; wrap != nil {
+		for _, c := range wrap.Creatives {
+			if c.Linear != nil {
+				each(c.Linear.TrackingEvents)
+			}
+			if c.NonLinearAds != nil {
+				each(c.NonLinearAds.TrackingEvents)
+				for _, nl := range c.NonLinearAds.NonLinears {
+					each(nl.TrackingEvents)
+				}
+			}
+			if c.CompanionAds != nil {
+				for _, comp := range c.CompanionAds.Companions {
+					each(comp.TrackingEvents)
+				}
+			}
+		}
+		extensions = wrap.Extensions
+	}
+	for _, e := range extensions {
+		each(e.CustomTracking)
+	}
+}
+
+func appendHost(hosts []string, host string) []string {
+	for _, h := range hosts {
+		if h == host {
+			return hosts
+		}
+	}
+	return append(hosts, host)
+}
+
+func countURIs(uris []string) int {
+	n := 0
+	for _, uri := range uris {
+		if uri != "" {
+			n++
+		}
+	}
+	return n
+}
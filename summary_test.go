@@ -0,0 +1,71 @@
+package vast
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_adaptv_attempt_attr.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s := v.Summarize()
+	assert.Equal(t, "3.0", s.Version)
+	if !assert.Len(t, s.Ads, 1) {
+		return
+	}
+	ad := s.Ads[0]
+	assert.Equal(t, "a583680", ad.ID)
+	assert.Equal(t, "inline", ad.Type)
+	assert.Equal(t, "Adap.tv 1.0", ad.AdSystem)
+	assert.Equal(t, 0, ad.WrapperDepth)
+	assert.Equal(t, Duration(15*time.Second), ad.Duration)
+	assert.Equal(t, 12, ad.Impressions)
+	assert.Equal(t, 1, ad.ErrorPixels)
+	assert.Equal(t, 5, ad.QuartileTrackers)
+	assert.Equal(t, 1, ad.Trackers[TRACK_MIDPOINT])
+	assert.Equal(t, 1, ad.ClickTrackers)
+	assert.Equal(t, 13, ad.MediaFiles)
+	assert.Equal(t, []string{"video/mp4", "video/webm", "video/x-flv"}, ad.MediaFormats)
+	assert.Contains(t, ad.Domains, "sb.scorecardresearch.com")
+	assert.Contains(t, ad.Domains, "cdn.adap.tv")
+
+	b, err := json.Marshal(s)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), `"pod_duration":"00:00:00"`)
+		assert.Contains(t, string(b), `"duration":"00:00:15"`)
+		assert.Contains(t, string(b), `"impressions":12`)
+	}
+}
+
+func TestSummarizeVerifications(t *testing.T) {
+	ext, err := NewExtension(AdVerifications{Verifications: []Verification{{
+		Vendor:              "company.com-omid",
+		JavaScriptResources: []JavaScriptResource{{APIFramework: "omid", URI: "https://verify.company.com/omid.js"}},
+	}}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	w, err := WrapTag("https://upstream.com/vast", WrapOptions{
+		Impressions: []Impression{{URI: "https://us.com/imp"}},
+		Trackings:   []Tracking{{Event: TRACK_START, URI: "https://us.com/start"}, {Event: TRACK_PAUSE, URI: "https://us.com/pause"}},
+		Extensions:  []Extension{ext},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s := w.Ads[0].Summarize()
+	assert.Equal(t, "wrapper", s.Type)
+	assert.Equal(t, 1, s.WrapperDepth)
+	assert.Equal(t, 1, s.Verifications)
+	assert.Equal(t, 1, s.QuartileTrackers)
+	assert.Equal(t, map[string]int{TRACK_START: 1, TRACK_PAUSE: 1}, s.Trackers)
+	assert.Equal(t, []string{"upstream.com", "us.com", "verify.company.com"}, s.Domains)
+}
@@ -0,0 +1,185 @@
+package vast
+
+// URIFunc is called for each URI of a document with the element path of the
+// URI, e.g. Ad[0]/InLine/Impression[1], and a pointer to the URI which may be
+// modified in place.
+type URIFunc func(path string, uri *string)
+
+// EachURI calls fn for every non empty URI of the document: error pixels,
+// impressions, trackers, clicks, media files, resources and wrapped tag URIs.
+// HTML resources, ad parameters and extension payloads are not visited.
+func (v *VAST) EachURI(fn URIFunc) {
+	for i := range v.Errors {
+		visitURI(pathElem("Error", i), &v.Errors[i].CDATA, fn)
+	}
+	for i := range v.Ads {
+		v.Ads[i].eachURI(pathElem("Ad", i), fn)
+	}
+}
+
+// EachURI calls fn for every non empty URI of the ad, see VAST.EachURI. Paths
+// are relative to the ad.
+func (ad *Ad) EachURI(fn URIFunc) {
+	ad.eachURI("", fn)
+}
+
+func (ad *Ad) eachURI(path string, fn URIFunc) {
+	if ad.InLine != nil {
+		path := joinPath(path, "InLine")
+		inline := ad.InLine
+		visitImpressions(path, inline.Impressions, inline.ViewableImpression, fn)
+		visitURI(joinPath(path, "Survey"), &inline.Survey.CDATA, fn)
+		for i := range inline.Errors {
+			visitURI(joinPath(path, pathElem("Error", i)), &inline.Errors[i].CDATA, fn)
+		}
+		for i := range inline.Creatives {
+			inline.Creatives[i].eachURI(joinPath(path, "Creatives", pathElem("Creative", i)), fn)
+		}
+		visitExtensions(joinPath(path, "Extensions"), inline.Extensions, fn)
+	} else if ad.Wrapper != nil {
+		path := joinPath(path, "Wrapper")
+		wrap := ad.Wrapper
+		visitURI(joinPath(path, "VASTAdTagURI"), &wrap.VASTAdTagURI.CDATA, fn)
+		visitImpressions(path, wrap.Impressions, wrap.ViewableImpression, fn)
+		for i := range wrap.Errors {
+			visitURI(joinPath(path, pathElem("Error", i)), &wrap.Errors[i].CDATA, fn)
+		}
+		for i := range wrap.Creatives {
+			wrap.Creatives[i].eachURI(joinPath(path, "Creatives", pathElem("Creative", i)), fn)
+		}
+		visitExtensions(joinPath(path, "Extensions"), wrap.Extensions, fn)
+	}
+}
+
+func (creative *Creative) eachURI(path string, fn URIFunc) {
+	if l := creative.Linear; l != nil {
+		path := joinPath(path, "Linear")
+		visitTrackings(joinPath(path, "TrackingEvents"), l.TrackingEvents, fn)
+		visitVideoClicks(joinPath(path, "VideoClicks"), l.VideoClicks, fn)
+		for i := range l.MediaFiles {
+			visitURI(joinPath(path, "MediaFiles", pathElem("MediaFile", i)), &l.MediaFiles[i].URI, fn)
+		}
+		visitIcons(joinPath(path, "Icons"), l.Icons, fn)
+	}
+	if n := creative.NonLinearAds; n != nil {
+		path := joinPath(path, "NonLinearAds")
+		visitTrackings(joinPath(path, "TrackingEvents"), n.TrackingEvents, fn)
+		for i := range n.NonLinears {
+			nl := &n.NonLinears[i]
+			path := joinPath(path, pathElem("NonLinear", i))
+			visitResources(path, nl.StaticResource, &nl.IFrameResource, fn)
+			visitURI(joinPath(path, "NonLinearClickThrough"), &nl.NonLinearClickThrough.CDATA, fn)
+			visitCDATAs(path, "NonLinearClickTracking", nl.NonLinearClickTracking, fn)
+		}
+	}
+	if c := creative.CompanionAds; c != nil {
+		path := joinPath(path, "CompanionAds")
+		for i := range c.Companions {
+			comp := &c.Companions[i]
+			path := joinPath(path, pathElem("Companion", i))
+			visitResources(path, comp.StaticResource, &comp.IFrameResource, fn)
+			visitURI(joinPath(path, "CompanionClickThrough"), &comp.CompanionClickThrough.CDATA, fn)
+			visitCDATAs(path, "CompanionClickTracking", comp.CompanionClickTracking, fn)
+			visitTrackings(joinPath(path, "TrackingEvents"), comp.TrackingEvents, fn)
+		}
+	}
+}
+
+func (creative *CreativeWrapper) eachURI(path string, fn URIFunc) {
+	if l := creative.Linear; l != nil {
+		path := joinPath(path, "Linear")
+		visitTrackings(joinPath(path, "TrackingEvents"), l.TrackingEvents, fn)
+		visitVideoClicks(joinPath(path, "VideoClicks"), l.VideoClicks, fn)
+		visitIcons(joinPath(path, "Icons"), l.Icons, fn)
+	}
+	if n := creative.NonLinearAds; n != nil {
+		path := joinPath(path, "NonLinearAds")
+		visitTrackings(joinPath(path, "TrackingEvents"), n.TrackingEvents, fn)
+		for i := range n.NonLinears {
+			nl := &n.NonLinears[i]
+			path := joinPath(path, pathElem("NonLinear", i))
+			visitTrackings(joinPath(path, "TrackingEvents"), nl.TrackingEvents, fn)
+			visitCDATAs(path, "NonLinearClickTracking", nl.NonLinearClickTracking, fn)
+		}
+	}
+	if c := creative.CompanionAds; c != nil {
+		path := joinPath(path, "CompanionAds")
+		for i := range c.Companions {
+			comp := &c.Companions[i]
+			path := joinPath(path, pathElem("Companion", i))
+			visitResources(path, comp.StaticResource, &comp.IFrameResource, fn)
+			visitURI(joinPath(path, "CompanionClickThrough"), &comp.CompanionClickThrough.CDATA, fn)
+			visitCDATAs(path, "CompanionClickTracking", comp.CompanionClickTracking, fn)
+			visitTrackings(joinPath(path, "TrackingEvents"), comp.TrackingEvents, fn)
+		}
+	}
+}
+
+func visitURI(path string, uri *string, fn URIFunc) {
+	if *uri != "" {
+		fn(path, uri)
+	}
+}
+
+func visitImpressions(path string, imps []Impression, views []Viewable, fn URIFunc) {
+	for i := range imps {
+		visitURI(joinPath(path, pathElem("Impression", i)), &imps[i].URI, fn)
+	}
+	for i := range views {
+		visitURI(joinPath(path, "ViewableImpression", pathElem("Viewable", i)), &views[i].URI, fn)
+	}
+}
+
+func visitTrackings(path string, trackings []Tracking, fn URIFunc) {
+	for i := range trackings {
+		visitURI(joinPath(path, pathElem("Tracking", i)), &trackings[i].URI, fn)
+	}
+}
+
+func visitCDATAs(path, name string, uris []CDATAString, fn URIFunc) {
+	for i := range uris {
+		visitURI(joinPath(path, pathElem(name, i)), &uris[i].CDATA, fn)
+	}
+}
+
+func visitVideoClicks(path string, clicks *VideoClicks, fn URIFunc) {
+	if clicks == nil {
+		return
+	}
+	for i := range clicks.ClickThroughs {
+		visitURI(joinPath(path, pathElem("ClickThrough", i)), &clicks.ClickThroughs[i].URI, fn)
+	}
+	for i := range clicks.ClickTrackings {
+		visitURI(joinPath(path, pathElem("ClickTracking", i)), &clicks.ClickTrackings[i].URI, fn)
+	}
+	for i := range clicks.CustomClicks {
+		visitURI(joinPath(path, pathElem("CustomClick", i)), &clicks.CustomClicks[i].URI, fn)
+	}
+}
+
+func visitResources(path string, static *StaticResource, iframe *CDATAString, fn URIFunc) {
+	if static != nil {
+		visitURI(joinPath(path, "StaticResource"), &static.URI, fn)
+	}
+	visitURI(joinPath(path, "IFrameResource"), &iframe.CDATA, fn)
+}
+
+func visitIcons(path string, icons *Icons, fn URIFunc) {
+	if icons == nil {
+		return
+	}
+	for i := range icons.Icon {
+		icon := &icons.Icon[i]
+		path := joinPath(path, pathElem("Icon", i))
+		visitResources(path, icon.StaticResource, &icon.IFrameResource, fn)
+		visitURI(joinPath(path, "IconClicks", "IconClickThrough"), &icon.IconClickThrough.CDATA, fn)
+		visitCDATAs(joinPath(path, "IconClicks"), "IconClickTracking", icon.IconClickTrackings, fn)
+		visitCDATAs(path, "IconViewTracking", icon.IconViewTrackings, fn)
+	}
+}
+
+func visitExtensions(path string, exts []Extension, fn URIFunc) {
+	for i := range exts {
+		visitTrackings(joinPath(path, pathElem("Extension", i), "CustomTracking"), exts[i].CustomTracking, fn)
+	}
+}
@@ -0,0 +1,43 @@
+package vast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEachURI(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_wrapper_linear_1.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	uris := map[string]string{}
+	v.EachURI(func(path string, uri *string) {
+		uris[path] = *uri
+	})
+
+	assert.Equal(t, "http://demo.tremormedia.com/proddev/vast/vast_inline_linear.xml", uris["Ad[0]/Wrapper/VASTAdTagURI"])
+	assert.Equal(t, "http://myErrorURL/wrapper/error", uris["Ad[0]/Wrapper/Error[0]"])
+	assert.Equal(t, "http://myTrackingURL/wrapper/impression", uris["Ad[0]/Wrapper/Impression[0]"])
+	assert.Equal(t, "http://myTrackingURL/wrapper/creativeView", uris["Ad[0]/Wrapper/Creatives/Creative[0]/Linear/TrackingEvents/Tracking[0]"])
+	assert.Equal(t, "http://myTrackingURL/wrapper/click", uris["Ad[0]/Wrapper/Creatives/Creative[1]/Linear/VideoClicks/ClickTracking[0]"])
+}
+
+func TestEachURIInline(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_inline_linear.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var paths []string
+	v.Ads[0].EachURI(func(path string, uri *string) {
+		paths = append(paths, path)
+		*uri = "x"
+	})
+
+	assert.Contains(t, paths, "InLine/Creatives/Creative[0]/Linear/MediaFiles/MediaFile[0]")
+	assert.Contains(t, paths, "InLine/Creatives/Creative[1]/CompanionAds/Companion[0]/StaticResource")
+	assert.Equal(t, "x", v.Ads[0].InLine.Creatives[0].Linear.MediaFiles[0].URI)
+	assert.Equal(t, "x", v.Ads[0].InLine.Impressions[0].URI)
+}
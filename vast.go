@@ -1,12 +1,17 @@
 // Package vast implements IAB VAST 3.0 specification http://www.iab.net/media/file/VASTv3.0.pdf
+//
+// The model also has a JSON encoding, described by the JSON Schema in
+// vast.schema.json: fields are snake_case, durations are hh:mm:ss[.mmm]
+// strings, offsets are durations or percentages such as "25%", CDATA values are
+// plain strings and the data of extensions is their raw inner XML.
 package vast
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"math"
-	"net/url"
 	"strings"
 )
 
@@ -65,29 +70,31 @@ type DisplayManage struct {
 // VAST is the root <VAST> tag
 type VAST struct {
 	// The version of the VAST spec (should be either "2.0" or "3.0")
-	Version string `xml:"version,attr"`
+	Version string `xml:"version,attr" json:"version"`
 	// One or more Ad elements. Advertisers and video content publishers may
 	// associate an <Ad> element with a line item video ad defined in contract
 	// documentation, usually an insertion order. These line item ads typically
 	// specify the creative to display, price, delivery schedule, targeting,
 	// and so on.
-	Ads []Ad `xml:"Ad"`
+	Ads []Ad `xml:"Ad" json:"ads,omitempty"`
 	// Contains a URI to a tracking resource that the video player should request
 	// upon receiving a “no ad” response
-	Errors []CDATAString `xml:"Error,omitempty"`
+	Errors []CDATAString `xml:"Error,omitempty" json:"errors,omitempty"`
+}
+
+// adSystem returns the AdSystem matching info
+func (info DisplayManage) adSystem() *AdSystem {
+	return &AdSystem{
+		Name:    info.Name,
+		Version: info.Ver,
+	}
 }
 
 func (v *VAST) SetDisplayManager(info DisplayManage) {
 	if v.Ads[0].Wrapper != nil {
-		v.Ads[0].Wrapper.AdSystem = &AdSystem{
-			Name:    info.Name,
-			Version: info.Ver,
-		}
+		v.Ads[0].Wrapper.AdSystem = info.adSystem()
 	} else if v.Ads[0].InLine != nil {
-		v.Ads[0].InLine.AdSystem = &AdSystem{
-			Name:    info.Name,
-			Version: info.Ver,
-		}
+		v.Ads[0].InLine.AdSystem = info.adSystem()
 		v.Ads[0].InLine.Advertiser = info.Title
 	}
 }
@@ -190,108 +197,44 @@ func (v *VAST) SetClickThrough(tracking VideoClick) {
 	}
 }
 
+// SetSecure rewrites every URI of the document to https (or http), see
+// UpgradeURLs.
 func (v *VAST) SetSecure(secure bool) {
-
-	for i, imp := range v.Errors {
-		v.Errors[i].CDATA = SecureUrl(imp.CDATA, secure)
-	}
-
-	for _, ad := range v.Ads {
-		ad.SetSecure(secure)
-	}
+	v.UpgradeURLs(secure)
 }
 
+// SetSecure rewrites every URI of the ad to https (or http), see UpgradeURL.
 func (ad *Ad) SetSecure(secure bool) {
-	if ad.Wrapper != nil {
-		ad.Wrapper.SetSecure(secure)
-	} else if ad.InLine != nil {
-		ad.InLine.SetSecure(secure)
-	}
+	ad.EachURI(secureURI(secure))
 }
 
+// SetSecure rewrites every URI of the wrapper to https (or http), see
+// Ad.SetSecure.
 func (wrap *Wrapper) SetSecure(secure bool) {
-
-	wrap.VASTAdTagURI.CDATA = SecureUrl(wrap.VASTAdTagURI.CDATA, secure)
-
-	for _, c := range wrap.Creatives {
-		c.SetSecure(secure)
-	}
-	for i, imp := range wrap.ViewableImpression {
-		wrap.ViewableImpression[i].URI = SecureUrl(imp.URI, secure)
-	}
-	for i, imp := range wrap.Impressions {
-		wrap.Impressions[i].URI = SecureUrl(imp.URI, secure)
-	}
-	for i, imp := range wrap.Errors {
-		wrap.Errors[i].CDATA = SecureUrl(imp.CDATA, secure)
-	}
+	(&Ad{Wrapper: wrap}).SetSecure(secure)
 }
 
-// validate InLine
+// SetSecure rewrites every URI of the inline ad to https (or http), see
+// Ad.SetSecure.
 func (inline *InLine) SetSecure(secure bool) {
-	for _, c := range inline.Creatives {
-		c.SetSecure(secure)
-	}
-	for i, imp := range inline.ViewableImpression {
-		inline.ViewableImpression[i].URI = SecureUrl(imp.URI, secure)
-	}
-	for i, imp := range inline.Impressions {
-		inline.Impressions[i].URI = SecureUrl(imp.URI, secure)
-	}
-	for i, imp := range inline.Errors {
-		inline.Errors[i].CDATA = SecureUrl(imp.CDATA, secure)
-	}
+	(&Ad{InLine: inline}).SetSecure(secure)
 }
 
-// validate InLine
+// SetSecure rewrites every URI of the wrapped creative to https (or http), see
+// Ad.SetSecure.
 func (creative *CreativeWrapper) SetSecure(secure bool) {
-
-	if creative.Linear != nil {
-		for i, t := range creative.Linear.TrackingEvents {
-			creative.Linear.TrackingEvents[i].URI = SecureUrl(t.URI, secure)
-		}
-
-		if creative.Linear.VideoClicks != nil {
-			for i, t := range creative.Linear.VideoClicks.ClickTrackings {
-				creative.Linear.VideoClicks.ClickTrackings[i].URI = SecureUrl(t.URI, secure)
-			}
-
-			for i, t := range creative.Linear.VideoClicks.ClickThroughs {
-				creative.Linear.VideoClicks.ClickThroughs[i].URI = SecureUrl(t.URI, secure)
-			}
-		}
-	} else if creative.NonLinearAds != nil {
-		for i, t := range creative.NonLinearAds.TrackingEvents {
-			creative.NonLinearAds.TrackingEvents[i].URI = SecureUrl(t.URI, secure)
-		}
-	}
+	creative.eachURI("", secureURI(secure))
 }
 
-// validate InLine
+// SetSecure rewrites every URI of the creative to https (or http), see
+// Ad.SetSecure.
 func (creative *Creative) SetSecure(secure bool) {
+	creative.eachURI("", secureURI(secure))
+}
 
-	if creative.Linear != nil {
-		for i, t := range creative.Linear.TrackingEvents {
-			creative.Linear.TrackingEvents[i].URI = SecureUrl(t.URI, secure)
-		}
-
-		if creative.Linear.VideoClicks != nil {
-			for i, t := range creative.Linear.VideoClicks.ClickTrackings {
-				creative.Linear.VideoClicks.ClickTrackings[i].URI = SecureUrl(t.URI, secure)
-			}
-
-			for i, t := range creative.Linear.VideoClicks.ClickThroughs {
-				creative.Linear.VideoClicks.ClickThroughs[i].URI = SecureUrl(t.URI, secure)
-			}
-		}
-
-		for i, t := range creative.Linear.MediaFiles {
-			creative.Linear.MediaFiles[i].URI = SecureUrl(t.URI, secure)
-		}
-	} else if creative.NonLinearAds != nil {
-		for i, t := range creative.NonLinearAds.TrackingEvents {
-			creative.NonLinearAds.TrackingEvents[i].URI = SecureUrl(t.URI, secure)
-		}
+func secureURI(secure bool) URIFunc {
+	return func(_ string, uri *string) {
+		*uri = SecureUrl(*uri, secure)
 	}
 }
 
@@ -387,13 +330,13 @@ func (v *VAST) FilterSize(w, h int) error {
 // Each <Ad> contains a single <InLine> element or <Wrapper> element (but never both).
 type Ad struct {
 	// An ad server-defined identifier string for the ad
-	ID string `xml:"id,attr,omitempty"`
+	ID string `xml:"id,attr,omitempty" json:"id,omitempty"`
 	// A number greater than zero (0) that identifies the sequence in which
 	// an ad should play; all <Ad> elements with sequence values are part of
 	// a pod and are intended to be played in sequence
-	Sequence int      `xml:"sequence,attr,omitempty"`
-	InLine   *InLine  `xml:",omitempty"`
-	Wrapper  *Wrapper `xml:",omitempty"`
+	Sequence int      `xml:"sequence,attr,omitempty" json:"sequence,omitempty"`
+	InLine   *InLine  `xml:",omitempty" json:"in_line,omitempty"`
+	Wrapper  *Wrapper `xml:",omitempty" json:"wrapper,omitempty"`
 }
 
 // validate AD
@@ -413,6 +356,17 @@ type CDATAString struct {
 	CDATA string `xml:",cdata"`
 }
 
+// MarshalJSON implements the json.Marshaler interface, a CDATAString is
+// encoded as a plain string.
+func (cdata CDATAString) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cdata.CDATA)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (cdata *CDATAString) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &cdata.CDATA)
+}
+
 // InLine is a vast <InLine> ad element containing actual ad definition
 //
 // The last ad server in the ad supply chain serves an <InLine> element.
@@ -420,43 +374,54 @@ type CDATAString struct {
 // URIs necessary to display the ad.
 type InLine struct {
 	// The name of the ad server that returned the ad
-	AdSystem *AdSystem
+	AdSystem *AdSystem `json:"ad_system,omitempty"`
 	// The common name of the ad
-	AdTitle CDATAString
+	AdTitle CDATAString `json:"ad_title,omitempty"`
 	// One or more URIs that directs the video player to a tracking resource file that the
 	// video player should request when the first frame of the ad is displayed
-	Impressions []Impression `xml:"Impression"`
+	Impressions []Impression `xml:"Impression" json:"impressions,omitempty"`
 	// MRC
-	ViewableImpression []Viewable `xml:"ViewableImpression>Viewable"`
+	ViewableImpression []Viewable `xml:"ViewableImpression>Viewable" json:"viewable_impressions,omitempty"`
 	// The container for one or more <Creative> elements
-	Creatives []Creative `xml:"Creatives>Creative"`
+	Creatives []Creative `xml:"Creatives>Creative" json:"creatives,omitempty"`
 	// A string value that provides a longer description of the ad.
-	Description CDATAString `xml:",omitempty"`
+	Description CDATAString `xml:",omitempty" json:"description,omitempty"`
 	// The name of the advertiser as defined by the ad serving party.
 	// This element can be used to prevent displaying ads with advertiser
 	// competitors. Ad serving parties and publishers should identify how
 	// to interpret values provided within this element. As with any optional
 	// elements, the video player is not required to support it.
-	Advertiser string `xml:",omitempty"`
+	Advertiser string `xml:",omitempty" json:"advertiser,omitempty"`
 	// A URI to a survey vendor that could be the survey, a tracking pixel,
 	// or anything to do with the survey. Multiple survey elements can be provided.
 	// A type attribute is available to specify the MIME type being served.
 	// For example, the attribute might be set to type=”text/javascript”.
 	// Surveys can be dynamically inserted into the VAST response as long as
 	// cross-domain issues are avoided.
-	Survey CDATAString `xml:",omitempty"`
+	Survey CDATAString `xml:",omitempty" json:"survey,omitempty"`
 	// A URI representing an error-tracking pixel; this element can occur multiple
 	// times.
-	Errors []CDATAString `xml:"Error,omitempty"`
+	Errors []CDATAString `xml:"Error,omitempty" json:"errors,omitempty"`
 	// Provides a value that represents a price that can be used by real-time bidding
 	// (RTB) systems. VAST is not designed to handle RTB since other methods exist,
 	// but this element is offered for custom solutions if needed.
-	Pricing string `xml:",omitempty"`
+	Pricing string `xml:",omitempty" json:"pricing,omitempty"`
 	// XML node for custom extensions, as defined by the ad server. When used, a
 	// custom element should be nested under <Extensions> to help separate custom
 	// XML elements from VAST elements. The following example includes a custom
 	// xml element within the Extensions element.
-	Extensions []Extension `xml:"Extensions>Extension,omitempty"`
+	Extensions []Extension `xml:"Extensions>Extension,omitempty" json:"extensions,omitempty"`
+	// VAST 4: the content categories of the creative, used for competitive
+	// separation and blocking
+	Categories []Category `xml:"Category,omitempty" json:"categories,omitempty"`
+}
+
+// Category is a VAST 4 content category of an ad, e.g. an IAB category code
+type Category struct {
+	// URL of the organizational authority that defines the codes, e.g.
+	// https://www.iabtechlab.com/categoriesv2
+	Authority string `xml:"authority,attr,omitempty" json:"authority,omitempty"`
+	Code      string `xml:",chardata" json:"code,omitempty"`
 }
 
 // validate InLine
@@ -509,15 +474,15 @@ func (inline *InLine) Validate() error {
 // Impression is a URI that directs the video player to a tracking resource file that
 // the video player should request when the first frame of the ad is displayed
 type Impression struct {
-	ID  string `xml:"id,attr,omitempty"`
-	URI string `xml:",cdata"`
+	ID  string `xml:"id,attr,omitempty" json:"id,omitempty"`
+	URI string `xml:",cdata" json:"uri,omitempty"`
 }
 
 // Viewable Impression is a URI that directs the video player to a tracking resource file that
 // the video player should request when the first frame of the ad is displayed
 type Viewable struct {
-	ID  string `xml:"id,attr,omitempty"`
-	URI string `xml:",cdata"`
+	ID  string `xml:"id,attr,omitempty" json:"id,omitempty"`
+	URI string `xml:",cdata" json:"uri,omitempty"`
 }
 
 // Pricing provides a value that represents a price that can be used by real-time
@@ -525,15 +490,15 @@ type Viewable struct {
 // exist,  but this element is offered for custom solutions if needed.
 type Pricing struct {
 	// Identifies the pricing model as one of "cpm", "cpc", "cpe" or "cpv".
-	Model string `xml:"model,attr"`
+	Model string `xml:"model,attr" json:"model,omitempty"`
 	// The 3 letter ISO-4217 currency symbol that identifies the currency of
 	// the value provided
-	Currency string `xml:"currency,attr"`
+	Currency string `xml:"currency,attr" json:"currency,omitempty"`
 	// If the value provided is to be obfuscated/encoded, publishers and advertisers
 	// must negotiate the appropriate mechanism to do so. When included as part of
 	// a VAST Wrapper in a chain of Wrappers, only the value offered in the first
 	// Wrapper need be considered.
-	Value string `xml:",cdata"`
+	Value string `xml:",cdata" json:"value,omitempty"`
 }
 
 // Wrapper element contains a URI reference to a vendor ad server (often called
@@ -544,28 +509,28 @@ type Pricing struct {
 // the ad.
 type Wrapper struct {
 	// The name of the ad server that returned the ad
-	AdSystem *AdSystem
+	AdSystem *AdSystem `json:"ad_system,omitempty"`
 	// URL of ad tag of downstream Secondary Ad Server
-	VASTAdTagURI CDATAString
+	VASTAdTagURI CDATAString `json:"vast_ad_tag_uri,omitempty"`
 	// One or more URIs that directs the video player to a tracking resource file that the
 	// video player should request when the first frame of the ad is displayed
-	Impressions []Impression `xml:"Impression"`
+	Impressions []Impression `xml:"Impression" json:"impressions,omitempty"`
 	// MRC
-	ViewableImpression []Viewable `xml:"ViewableImpression>Viewable"`
+	ViewableImpression []Viewable `xml:"ViewableImpression>Viewable" json:"viewable_impressions,omitempty"`
 	// A URI representing an error-tracking pixel; this element can occur multiple
 	// times.
-	Errors []CDATAString `xml:"Error,omitempty"`
+	Errors []CDATAString `xml:"Error,omitempty" json:"errors,omitempty"`
 	// The container for one or more <Creative> elements
-	Creatives []CreativeWrapper `xml:"Creatives>Creative"`
+	Creatives []CreativeWrapper `xml:"Creatives>Creative" json:"creatives,omitempty"`
 	// XML node for custom extensions, as defined by the ad server. When used, a
 	// custom element should be nested under <Extensions> to help separate custom
 	// XML elements from VAST elements. The following example includes a custom
 	// xml element within the Extensions element.
-	Extensions []Extension `xml:"Extensions>Extension,omitempty"`
+	Extensions []Extension `xml:"Extensions>Extension,omitempty" json:"extensions,omitempty"`
 
-	FallbackOnNoAd           *bool `xml:"fallbackOnNoAd,attr,omitempty"`
-	AllowMultipleAds         *bool `xml:"allowMultipleAds,attr,omitempty"`
-	FollowAdditionalWrappers *bool `xml:"followAdditionalWrappers,attr,omitempty"`
+	FallbackOnNoAd           *bool `xml:"fallbackOnNoAd,attr,omitempty" json:"fallback_on_no_ad,omitempty"`
+	AllowMultipleAds         *bool `xml:"allowMultipleAds,attr,omitempty" json:"allow_multiple_ads,omitempty"`
+	FollowAdditionalWrappers *bool `xml:"followAdditionalWrappers,attr,omitempty" json:"follow_additional_wrappers,omitempty"`
 }
 
 // validate InLine
@@ -594,26 +559,26 @@ func (wrap *Wrapper) Validate() error {
 
 // AdSystem contains information about the system that returned the ad
 type AdSystem struct {
-	Version string `xml:"version,attr,omitempty"`
-	Name    string `xml:",cdata"`
+	Version string `xml:"version,attr,omitempty" json:"version,omitempty"`
+	Name    string `xml:",cdata" json:"name,omitempty"`
 }
 
 // Creative is a file that is part of a VAST ad.
 type Creative struct {
 	// An ad server-defined identifier for the creative
-	ID string `xml:"id,attr,omitempty"`
+	ID string `xml:"id,attr,omitempty" json:"id,omitempty"`
 	// The preferred order in which multiple Creatives should be displayed
-	Sequence int `xml:"sequence,attr,omitempty"`
+	Sequence int `xml:"sequence,attr,omitempty" json:"sequence,omitempty"`
 	// Identifies the ad with which the creative is served
-	AdID string `xml:"AdID,attr,omitempty"`
+	AdID string `xml:"AdID,attr,omitempty" json:"ad_id,omitempty"`
 	// The technology used for any included API
-	APIFramework string `xml:"apiFramework,attr,omitempty"`
+	APIFramework string `xml:"apiFramework,attr,omitempty" json:"api_framework,omitempty"`
 	// If present, defines a linear creative
-	Linear *Linear `xml:",omitempty"`
+	Linear *Linear `xml:",omitempty" json:"linear,omitempty"`
 	// If defined, defins companions creatives
-	CompanionAds *CompanionAds `xml:",omitempty"`
+	CompanionAds *CompanionAds `xml:",omitempty" json:"companion_ads,omitempty"`
 	// If defined, defines non linear creatives
-	NonLinearAds *NonLinearAds `xml:",omitempty"`
+	NonLinearAds *NonLinearAds `xml:",omitempty" json:"non_linear_ads,omitempty"`
 	// When an API framework is needed to execute creative, a
 	// <CreativeExtensions> element can be added under the <Creative>. This
 	// extension can be used to load an executable creative with or without using
@@ -644,15 +609,15 @@ type CompanionAds struct {
 	// Provides information about which companion creative to display.
 	// All means that the player must attempt to display all. Any means the player
 	// must attempt to play at least one. None means all companions are optional
-	Required   string      `xml:"required,attr,omitempty"`
-	Companions []Companion `xml:"Companion,omitempty"`
+	Required   string      `xml:"required,attr,omitempty" json:"required,omitempty"`
+	Companions []Companion `xml:"Companion,omitempty" json:"companions,omitempty"`
 }
 
 // NonLinearAds contains non linear creatives
 type NonLinearAds struct {
-	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty"`
+	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty" json:"tracking_events,omitempty"`
 	// Non linear creatives
-	NonLinears []NonLinear `xml:"NonLinear,omitempty"`
+	NonLinears []NonLinear `xml:"NonLinear,omitempty" json:"non_linears,omitempty"`
 }
 
 // validate NonLinearAds
@@ -664,17 +629,17 @@ func (nonlinear *NonLinearAds) Validate() error {
 // CreativeWrapper defines wrapped creative's parent trackers
 type CreativeWrapper struct {
 	// An ad server-defined identifier for the creative
-	ID string `xml:"id,attr,omitempty"`
+	ID string `xml:"id,attr,omitempty" json:"id,omitempty"`
 	// The preferred order in which multiple Creatives should be displayed
-	Sequence int `xml:"sequence,attr,omitempty"`
+	Sequence int `xml:"sequence,attr,omitempty" json:"sequence,omitempty"`
 	// Identifies the ad with which the creative is served
-	AdID string `xml:"AdID,attr,omitempty"`
+	AdID string `xml:"AdID,attr,omitempty" json:"ad_id,omitempty"`
 	// If present, defines a linear creative
-	Linear *LinearWrapper `xml:",omitempty"`
+	Linear *LinearWrapper `xml:",omitempty" json:"linear,omitempty"`
 	// If defined, defins companions creatives
-	CompanionAds *CompanionAdsWrapper `xml:"CompanionAds,omitempty"`
+	CompanionAds *CompanionAdsWrapper `xml:"CompanionAds,omitempty" json:"companion_ads,omitempty"`
 	// If defined, defines non linear creatives
-	NonLinearAds *NonLinearAdsWrapper `xml:"NonLinearAds,omitempty"`
+	NonLinearAds *NonLinearAdsWrapper `xml:"NonLinearAds,omitempty" json:"non_linear_ads,omitempty"`
 }
 
 // CompanionAdsWrapper contains companions creatives in a wrapper
@@ -682,15 +647,15 @@ type CompanionAdsWrapper struct {
 	// Provides information about which companion creative to display.
 	// All means that the player must attempt to display all. Any means the player
 	// must attempt to play at least one. None means all companions are optional
-	Required   string             `xml:"required,attr,omitempty"`
-	Companions []CompanionWrapper `xml:"Companion,omitempty"`
+	Required   string             `xml:"required,attr,omitempty" json:"required,omitempty"`
+	Companions []CompanionWrapper `xml:"Companion,omitempty" json:"companions,omitempty"`
 }
 
 // NonLinearAdsWrapper contains non linear creatives in a wrapper
 type NonLinearAdsWrapper struct {
-	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty"`
+	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty" json:"tracking_events,omitempty"`
 	// Non linear creatives
-	NonLinears []NonLinearWrapper `xml:"NonLinear,omitempty"`
+	NonLinears []NonLinearWrapper `xml:"NonLinear,omitempty" json:"non_linears,omitempty"`
 }
 
 // Linear is the most common type of video advertisement trafficked in the
@@ -708,14 +673,14 @@ type Linear struct {
 	// represents milliseconds and is optional. This skipoffset value
 	// indicates when the skip control should be provided after the creative
 	// begins playing.
-	SkipOffset *Offset `xml:"skipoffset,attr,omitempty"`
+	SkipOffset *Offset `xml:"skipoffset,attr,omitempty" json:"skip_offset,omitempty"`
 	// Duration in standard time format, hh:mm:ss
-	Duration       Duration
-	AdParameters   *AdParameters `xml:",omitempty"`
-	Icons          *Icons
-	TrackingEvents []Tracking   `xml:"TrackingEvents>Tracking,omitempty"`
-	VideoClicks    *VideoClicks `xml:",omitempty"`
-	MediaFiles     []MediaFile  `xml:"MediaFiles>MediaFile,omitempty"`
+	Duration       Duration      `json:"duration,omitempty"`
+	AdParameters   *AdParameters `xml:",omitempty" json:"ad_parameters,omitempty"`
+	Icons          *Icons        `json:"icons,omitempty"`
+	TrackingEvents []Tracking    `xml:"TrackingEvents>Tracking,omitempty" json:"tracking_events,omitempty"`
+	VideoClicks    *VideoClicks  `xml:",omitempty" json:"video_clicks,omitempty"`
+	MediaFiles     []MediaFile   `xml:"MediaFiles>MediaFile,omitempty" json:"media_files,omitempty"`
 }
 
 // validate InLine
@@ -763,188 +728,190 @@ func (linear *Linear) Validate() error {
 
 // LinearWrapper defines a wrapped linear creative
 type LinearWrapper struct {
-	Icons          *Icons
-	TrackingEvents []Tracking   `xml:"TrackingEvents>Tracking,omitempty"`
-	VideoClicks    *VideoClicks `xml:",omitempty"`
+	Icons          *Icons       `json:"icons,omitempty"`
+	TrackingEvents []Tracking   `xml:"TrackingEvents>Tracking,omitempty" json:"tracking_events,omitempty"`
+	VideoClicks    *VideoClicks `xml:",omitempty" json:"video_clicks,omitempty"`
 }
 
 // Companion defines a companion ad
 type Companion struct {
 	// Optional identifier
-	ID string `xml:"id,attr,omitempty"`
+	ID string `xml:"id,attr,omitempty" json:"id,omitempty"`
 	// Pixel dimensions of companion slot.
-	Width int `xml:"width,attr"`
+	Width int `xml:"width,attr" json:"width,omitempty"`
 	// Pixel dimensions of companion slot.
-	Height int `xml:"height,attr"`
+	Height int `xml:"height,attr" json:"height,omitempty"`
 	// Pixel dimensions of the companion asset.
-	AssetWidth int `xml:"assetWidth,attr"`
+	AssetWidth int `xml:"assetWidth,attr" json:"asset_width,omitempty"`
 	// Pixel dimensions of the companion asset.
-	AssetHeight int `xml:"assetHeight,attr"`
+	AssetHeight int `xml:"assetHeight,attr" json:"asset_height,omitempty"`
 	// Pixel dimensions of expanding companion ad when in expanded state.
-	ExpandedWidth int `xml:"expandedWidth,attr"`
+	ExpandedWidth int `xml:"expandedWidth,attr" json:"expanded_width,omitempty"`
 	// Pixel dimensions of expanding companion ad when in expanded state.
-	ExpandeHeight int `xml:"expandedHeight,attr"`
+	ExpandeHeight int `xml:"expandedHeight,attr" json:"expanded_height,omitempty"`
 	// The apiFramework defines the method to use for communication with the companion.
-	APIFramework string `xml:"apiFramework,attr,omitempty"`
+	APIFramework string `xml:"apiFramework,attr,omitempty" json:"api_framework,omitempty"`
 	// Used to match companion creative to publisher placement areas on the page.
-	AdSlotID string `xml:"adSlotId,attr,omitempty"`
+	AdSlotID string `xml:"adSlotId,attr,omitempty" json:"ad_slot_id,omitempty"`
 	// URL to open as destination page when user clicks on the the companion banner ad.
-	CompanionClickThrough CDATAString `xml:",omitempty"`
+	CompanionClickThrough CDATAString `xml:",omitempty" json:"companion_click_through,omitempty"`
 	// URLs to ping when user clicks on the the companion banner ad.
-	CompanionClickTracking []CDATAString `xml:",omitempty"`
+	CompanionClickTracking []CDATAString `xml:",omitempty" json:"companion_click_trackings,omitempty"`
 	// Alt text to be displayed when companion is rendered in HTML environment.
-	AltText string `xml:",omitempty"`
+	AltText string `xml:",omitempty" json:"alt_text,omitempty"`
 	// The creativeView should always be requested when present. For Companions
 	// creativeView is the only supported event.
-	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty"`
+	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty" json:"tracking_events,omitempty"`
 	// Data to be passed into the companion ads. The apiFramework defines the method
 	// to use for communication (e.g. “FlashVar”)
-	AdParameters *AdParameters `xml:",omitempty"`
+	AdParameters *AdParameters `xml:",omitempty" json:"ad_parameters,omitempty"`
 	// URL to a static file, such as an image or SWF file
-	StaticResource *StaticResource `xml:",omitempty"`
+	StaticResource *StaticResource `xml:",omitempty" json:"static_resource,omitempty"`
 	// URL source for an IFrame to display the companion element
-	IFrameResource CDATAString `xml:",omitempty"`
+	IFrameResource CDATAString `xml:",omitempty" json:"iframe_resource,omitempty"`
 	// HTML to display the companion element
-	HTMLResource *HTMLResource `xml:",omitempty"`
+	HTMLResource *HTMLResource `xml:",omitempty" json:"html_resource,omitempty"`
 }
 
 // CompanionWrapper defines a companion ad in a wrapper
 type CompanionWrapper struct {
 	// Optional identifier
-	ID string `xml:"id,attr,omitempty"`
+	ID string `xml:"id,attr,omitempty" json:"id,omitempty"`
 	// Pixel dimensions of companion slot.
-	Width int `xml:"width,attr"`
+	Width int `xml:"width,attr" json:"width,omitempty"`
 	// Pixel dimensions of companion slot.
-	Height int `xml:"height,attr"`
+	Height int `xml:"height,attr" json:"height,omitempty"`
 	// Pixel dimensions of the companion asset.
-	AssetWidth int `xml:"assetWidth,attr"`
+	AssetWidth int `xml:"assetWidth,attr" json:"asset_width,omitempty"`
 	// Pixel dimensions of the companion asset.
-	AssetHeight int `xml:"assetHeight,attr"`
+	AssetHeight int `xml:"assetHeight,attr" json:"asset_height,omitempty"`
 	// Pixel dimensions of expanding companion ad when in expanded state.
-	ExpandedWidth int `xml:"expandedWidth,attr"`
+	ExpandedWidth int `xml:"expandedWidth,attr" json:"expanded_width,omitempty"`
 	// Pixel dimensions of expanding companion ad when in expanded state.
-	ExpandeHeight int `xml:"expandedHeight,attr"`
+	ExpandeHeight int `xml:"expandedHeight,attr" json:"expanded_height,omitempty"`
 	// The apiFramework defines the method to use for communication with the companion.
-	APIFramework string `xml:"apiFramework,attr,omitempty"`
+	APIFramework string `xml:"apiFramework,attr,omitempty" json:"api_framework,omitempty"`
 	// Used to match companion creative to publisher placement areas on the page.
-	AdSlotID string `xml:"adSlotId,attr,omitempty"`
+	AdSlotID string `xml:"adSlotId,attr,omitempty" json:"ad_slot_id,omitempty"`
 	// URL to open as destination page when user clicks on the the companion banner ad.
-	CompanionClickThrough CDATAString `xml:",omitempty"`
+	CompanionClickThrough CDATAString `xml:",omitempty" json:"companion_click_through,omitempty"`
 	// URLs to ping when user clicks on the the companion banner ad.
-	CompanionClickTracking []CDATAString `xml:",omitempty"`
+	CompanionClickTracking []CDATAString `xml:",omitempty" json:"companion_click_trackings,omitempty"`
 	// Alt text to be displayed when companion is rendered in HTML environment.
-	AltText string `xml:",omitempty"`
+	AltText string `xml:",omitempty" json:"alt_text,omitempty"`
 	// The creativeView should always be requested when present. For Companions
 	// creativeView is the only supported event.
-	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty"`
+	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty" json:"tracking_events,omitempty"`
 	// Data to be passed into the companion ads. The apiFramework defines the method
 	// to use for communication (e.g. “FlashVar”)
-	AdParameters *AdParameters `xml:",omitempty"`
+	AdParameters *AdParameters `xml:",omitempty" json:"ad_parameters,omitempty"`
 	// URL to a static file, such as an image or SWF file
-	StaticResource *StaticResource `xml:",omitempty"`
+	StaticResource *StaticResource `xml:",omitempty" json:"static_resource,omitempty"`
 	// URL source for an IFrame to display the companion element
-	IFrameResource CDATAString `xml:",omitempty"`
+	IFrameResource CDATAString `xml:",omitempty" json:"iframe_resource,omitempty"`
 	// HTML to display the companion element
-	HTMLResource *HTMLResource `xml:",omitempty"`
+	HTMLResource *HTMLResource `xml:",omitempty" json:"html_resource,omitempty"`
 }
 
 // NonLinear defines a non linear ad
 type NonLinear struct {
 	// Optional identifier
-	ID string `xml:"id,attr,omitempty"`
+	ID string `xml:"id,attr,omitempty" json:"id,omitempty"`
 	// Pixel dimensions of companion.
-	Width int `xml:"width,attr"`
+	Width int `xml:"width,attr" json:"width,omitempty"`
 	// Pixel dimensions of companion.
-	Height int `xml:"height,attr"`
+	Height int `xml:"height,attr" json:"height,omitempty"`
 	// Pixel dimensions of expanding nonlinear ad when in expanded state.
-	ExpandedWidth int `xml:"expandedWidth,attr"`
+	ExpandedWidth int `xml:"expandedWidth,attr" json:"expanded_width,omitempty"`
 	// Pixel dimensions of expanding nonlinear ad when in expanded state.
-	ExpandeHeight int `xml:"expandedHeight,attr"`
+	ExpandeHeight int `xml:"expandedHeight,attr" json:"expanded_height,omitempty"`
 	// Whether it is acceptable to scale the image.
-	Scalable bool `xml:"scalable,attr,omitempty"`
+	Scalable bool `xml:"scalable,attr,omitempty" json:"scalable,omitempty"`
 	// Whether the ad must have its aspect ratio maintained when scales.
-	MaintainAspectRatio bool `xml:"maintainAspectRatio,attr,omitempty"`
+	MaintainAspectRatio bool `xml:"maintainAspectRatio,attr,omitempty" json:"maintain_aspect_ratio,omitempty"`
 	// Suggested duration to display non-linear ad, typically for animation to complete.
 	// Expressed in standard time format hh:mm:ss.
-	MinSuggestedDuration *Duration `xml:"minSuggestedDuration,attr,omitempty"`
+	MinSuggestedDuration *Duration `xml:"minSuggestedDuration,attr,omitempty" json:"min_suggested_duration,omitempty"`
 	// The apiFramework defines the method to use for communication with the nonlinear element.
-	APIFramework string `xml:"apiFramework,attr,omitempty"`
+	APIFramework string `xml:"apiFramework,attr,omitempty" json:"api_framework,omitempty"`
 	// URLs to ping when user clicks on the the non-linear ad.
-	NonLinearClickTracking []CDATAString `xml:",omitempty"`
+	NonLinearClickTracking []CDATAString `xml:",omitempty" json:"non_linear_click_trackings,omitempty"`
 	// URL to open as destination page when user clicks on the non-linear ad unit.
-	NonLinearClickThrough CDATAString `xml:",omitempty"`
+	NonLinearClickThrough CDATAString `xml:",omitempty" json:"non_linear_click_through,omitempty"`
 	// Data to be passed into the video ad.
-	AdParameters *AdParameters `xml:",omitempty"`
+	AdParameters *AdParameters `xml:",omitempty" json:"ad_parameters,omitempty"`
 	// URL to a static file, such as an image or SWF file
-	StaticResource *StaticResource `xml:",omitempty"`
+	StaticResource *StaticResource `xml:",omitempty" json:"static_resource,omitempty"`
 	// URL source for an IFrame to display the companion element
-	IFrameResource CDATAString `xml:",omitempty"`
+	IFrameResource CDATAString `xml:",omitempty" json:"iframe_resource,omitempty"`
 	// HTML to display the companion element
-	HTMLResource *HTMLResource `xml:",omitempty"`
+	HTMLResource *HTMLResource `xml:",omitempty" json:"html_resource,omitempty"`
 }
 
 // NonLinearWrapper defines a non linear ad in a wrapper
 type NonLinearWrapper struct {
 	// Optional identifier
-	ID string `xml:"id,attr,omitempty"`
+	ID string `xml:"id,attr,omitempty" json:"id,omitempty"`
 	// Pixel dimensions of companion.
-	Width int `xml:"width,attr"`
+	Width int `xml:"width,attr" json:"width,omitempty"`
 	// Pixel dimensions of companion.
-	Height int `xml:"height,attr"`
+	Height int `xml:"height,attr" json:"height,omitempty"`
 	// Pixel dimensions of expanding nonlinear ad when in expanded state.
-	ExpandedWidth int `xml:"expandedWidth,attr"`
+	ExpandedWidth int `xml:"expandedWidth,attr" json:"expanded_width,omitempty"`
 	// Pixel dimensions of expanding nonlinear ad when in expanded state.
-	ExpandeHeight int `xml:"expandedHeight,attr"`
+	ExpandeHeight int `xml:"expandedHeight,attr" json:"expanded_height,omitempty"`
 	// Whether it is acceptable to scale the image.
-	Scalable bool `xml:"scalable,attr,omitempty"`
+	Scalable bool `xml:"scalable,attr,omitempty" json:"scalable,omitempty"`
 	// Whether the ad must have its aspect ratio maintained when scales.
-	MaintainAspectRatio bool `xml:"maintainAspectRatio,attr,omitempty"`
+	MaintainAspectRatio bool `xml:"maintainAspectRatio,attr,omitempty" json:"maintain_aspect_ratio,omitempty"`
 	// Suggested duration to display non-linear ad, typically for animation to complete.
 	// Expressed in standard time format hh:mm:ss.
-	MinSuggestedDuration *Duration `xml:"minSuggestedDuration,attr,omitempty"`
+	MinSuggestedDuration *Duration `xml:"minSuggestedDuration,attr,omitempty" json:"min_suggested_duration,omitempty"`
 	// The apiFramework defines the method to use for communication with the nonlinear element.
-	APIFramework string `xml:"apiFramework,attr,omitempty"`
+	APIFramework string `xml:"apiFramework,attr,omitempty" json:"api_framework,omitempty"`
 	// The creativeView should always be requested when present.
-	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty"`
+	TrackingEvents []Tracking `xml:"TrackingEvents>Tracking,omitempty" json:"tracking_events,omitempty"`
 	// URLs to ping when user clicks on the the non-linear ad.
-	NonLinearClickTracking []CDATAString `xml:",omitempty"`
+	NonLinearClickTracking []CDATAString `xml:",omitempty" json:"non_linear_click_trackings,omitempty"`
 }
 
 type Icons struct {
-	XMLName xml.Name `xml:"Icons,omitempty"`
-	Icon    []Icon   `xml:"Icon,omitempty"`
+	XMLName xml.Name `xml:"Icons,omitempty" json:"-"`
+	Icon    []Icon   `xml:"Icon,omitempty" json:"icon,omitempty"`
 }
 
 // Icon represents advertising industry initiatives like AdChoices.
 type Icon struct {
 	// Identifies the industry initiative that the icon supports.
-	Program string `xml:"program,attr"`
+	Program string `xml:"program,attr" json:"program,omitempty"`
 	// Pixel dimensions of icon.
-	Width int `xml:"width,attr"`
+	Width int `xml:"width,attr" json:"width,omitempty"`
 	// Pixel dimensions of icon.
-	Height int `xml:"height,attr"`
+	Height int `xml:"height,attr" json:"height,omitempty"`
 	// The horizontal alignment location (in pixels) or a specific alignment.
 	// Must match ([0-9]*|left|right)
-	XPosition string `xml:"xPosition,attr"`
+	XPosition string `xml:"xPosition,attr" json:"x_position,omitempty"`
 	// The vertical alignment location (in pixels) or a specific alignment.
 	// Must match ([0-9]*|top|bottom)
-	YPosition string `xml:"yPosition,attr"`
+	YPosition string `xml:"yPosition,attr" json:"y_position,omitempty"`
 	// Start time at which the player should display the icon. Expressed in standard time format hh:mm:ss.
-	Offset Offset `xml:"offset,attr"`
+	Offset Offset `xml:"offset,attr" json:"offset,omitempty"`
 	// duration for which the player must display the icon. Expressed in standard time format hh:mm:ss.
-	Duration Duration `xml:"duration,attr"`
+	Duration Duration `xml:"duration,attr" json:"duration,omitempty"`
 	// The apiFramework defines the method to use for communication with the icon element
-	APIFramework string `xml:"apiFramework,attr,omitempty"`
+	APIFramework string `xml:"apiFramework,attr,omitempty" json:"api_framework,omitempty"`
 	// URL to open as destination page when user clicks on the icon.
-	IconClickThrough CDATAString `xml:"IconClicks>IconClickThrough,omitempty"`
+	IconClickThrough CDATAString `xml:"IconClicks>IconClickThrough,omitempty" json:"icon_click_through,omitempty"`
 	// URLs to ping when user clicks on the the icon.
-	IconClickTrackings []CDATAString `xml:"IconClicks>IconClickTracking,omitempty"`
+	IconClickTrackings []CDATAString `xml:"IconClicks>IconClickTracking,omitempty" json:"icon_click_trackings,omitempty"`
+	// URLs to ping when the icon is displayed.
+	IconViewTrackings []CDATAString `xml:"IconViewTracking,omitempty" json:"icon_view_trackings,omitempty"`
 	// URL to a static file, such as an image or SWF file
-	StaticResource *StaticResource `xml:",omitempty"`
+	StaticResource *StaticResource `xml:",omitempty" json:"static_resource,omitempty"`
 	// URL source for an IFrame to display the companion element
-	IFrameResource CDATAString `xml:",omitempty"`
+	IFrameResource CDATAString `xml:",omitempty" json:"iframe_resource,omitempty"`
 	// HTML to display the companion element
-	HTMLResource *HTMLResource `xml:",omitempty"`
+	HTMLResource *HTMLResource `xml:",omitempty" json:"html_resource,omitempty"`
 }
 
 // Tracking defines an event tracking URL
@@ -955,11 +922,11 @@ type Tracking struct {
 	// Possible values are creativeView, start, firstQuartile, midpoint, thirdQuartile,
 	// complete, mute, unmute, pause, rewind, resume, fullscreen, exitFullscreen, expand,
 	// collapse, acceptInvitation, close, skip, progress.
-	Event string `xml:"event,attr"`
+	Event string `xml:"event,attr" json:"event,omitempty"`
 	// The time during the video at which this url should be pinged. Must be present for
 	// progress event. Must match (\d{2}:[0-5]\d:[0-5]\d(\.\d\d\d)?|1?\d?\d(\.?\d)*%)
-	Offset *Offset `xml:"offset,attr,omitempty"`
-	URI    string  `xml:",cdata"`
+	Offset *Offset `xml:"offset,attr,omitempty" json:"offset,omitempty"`
+	URI    string  `xml:",cdata" json:"uri,omitempty"`
 }
 
 // validate Tracking
@@ -973,30 +940,30 @@ func (track *Tracking) Validate() error {
 // StaticResource is the URL to a static file, such as an image or SWF file
 type StaticResource struct {
 	// Mime type of static resource
-	CreativeType string `xml:"creativeType,attr,omitempty"`
+	CreativeType string `xml:"creativeType,attr,omitempty" json:"creative_type,omitempty"`
 	// URL to a static file, such as an image or SWF file
-	URI string `xml:",cdata"`
+	URI string `xml:",cdata" json:"uri,omitempty"`
 }
 
 // HTMLResource is a container for HTML data
 type HTMLResource struct {
 	// Specifies whether the HTML is XML-encoded
-	XMLEncoded bool   `xml:"xmlEncoded,attr,omitempty"`
-	HTML       string `xml:",cdata"`
+	XMLEncoded bool   `xml:"xmlEncoded,attr,omitempty" json:"xml_encoded,omitempty"`
+	HTML       string `xml:",cdata" json:"html,omitempty"`
 }
 
 // AdParameters defines arbitrary ad parameters
 type AdParameters struct {
 	// Specifies whether the parameters are XML-encoded
-	XMLEncoded bool   `xml:"xmlEncoded,attr,omitempty"`
-	Parameters string `xml:",cdata"`
+	XMLEncoded bool   `xml:"xmlEncoded,attr,omitempty" json:"xml_encoded,omitempty"`
+	Parameters string `xml:",cdata" json:"parameters,omitempty"`
 }
 
 // VideoClicks contains types of video clicks
 type VideoClicks struct {
-	ClickThroughs  []VideoClick `xml:"ClickThrough,omitempty"`
-	ClickTrackings []VideoClick `xml:"ClickTracking,omitempty"`
-	CustomClicks   []VideoClick `xml:"CustomClick,omitempty"`
+	ClickThroughs  []VideoClick `xml:"ClickThrough,omitempty" json:"click_throughs,omitempty"`
+	ClickTrackings []VideoClick `xml:"ClickTracking,omitempty" json:"click_trackings,omitempty"`
+	CustomClicks   []VideoClick `xml:"CustomClick,omitempty" json:"custom_clicks,omitempty"`
 }
 
 // validate VideoClicks
@@ -1046,46 +1013,46 @@ func (click *VideoClicks) Validate() error {
 
 // VideoClick defines a click URL for a linear creative
 type VideoClick struct {
-	ID  string `xml:"id,attr,omitempty"`
-	URI string `xml:",cdata"`
+	ID  string `xml:"id,attr,omitempty" json:"id,omitempty"`
+	URI string `xml:",cdata" json:"uri,omitempty"`
 }
 
 // MediaFile defines a reference to a linear creative asset
 type MediaFile struct {
 	// Optional identifier
-	ID string `xml:"id,attr,omitempty"`
+	ID string `xml:"id,attr,omitempty" json:"id,omitempty"`
 	// Method of delivery of ad (either "streaming" or "progressive")
-	Delivery string `xml:"delivery,attr"`
+	Delivery string `xml:"delivery,attr" json:"delivery,omitempty"`
 	// MIME type. Popular MIME types include, but are not limited to
 	// “video/x-ms-wmv” for Windows Media, and “video/x-flv” for Flash
 	// Video. Image ads or interactive ads can be included in the
 	// MediaFiles section with appropriate Mime types
-	Type string `xml:"type,attr"`
+	Type string `xml:"type,attr" json:"type,omitempty"`
 	// The codec used to produce the media file.
-	Codec string `xml:"codec,attr,omitempty"`
+	Codec string `xml:"codec,attr,omitempty" json:"codec,omitempty"`
 	// Bitrate of encoded video in Kbps. If bitrate is supplied, MinBitrate
 	// and MaxBitrate should not be supplied.
-	Bitrate int `xml:"bitrate,attr,omitempty"`
+	Bitrate int `xml:"bitrate,attr,omitempty" json:"bitrate,omitempty"`
 	// Minimum bitrate of an adaptive stream in Kbps. If MinBitrate is supplied,
 	// MaxBitrate must be supplied and Bitrate should not be supplied.
-	MinBitrate int `xml:"minBitrate,attr,omitempty"`
+	MinBitrate int `xml:"minBitrate,attr,omitempty" json:"min_bitrate,omitempty"`
 	// Maximum bitrate of an adaptive stream in Kbps. If MaxBitrate is supplied,
 	// MinBitrate must be supplied and Bitrate should not be supplied.
-	MaxBitrate int `xml:"maxBitrate,attr,omitempty"`
+	MaxBitrate int `xml:"maxBitrate,attr,omitempty" json:"max_bitrate,omitempty"`
 	// Pixel dimensions of video.
-	Width int `xml:"width,attr"`
+	Width int `xml:"width,attr" json:"width,omitempty"`
 	// Pixel dimensions of video.
-	Height int `xml:"height,attr"`
+	Height int `xml:"height,attr" json:"height,omitempty"`
 	// Whether it is acceptable to scale the image.
-	Scalable bool `xml:"scalable,attr,omitempty"`
+	Scalable bool `xml:"scalable,attr,omitempty" json:"scalable,omitempty"`
 	// Whether the ad must have its aspect ratio maintained when scales.
-	MaintainAspectRatio bool `xml:"maintainAspectRatio,attr,omitempty"`
+	MaintainAspectRatio bool `xml:"maintainAspectRatio,attr,omitempty" json:"maintain_aspect_ratio,omitempty"`
 	// The APIFramework defines the method to use for communication if the MediaFile
 	// is interactive. Suggested values for this element are “VPAID”, “FlashVars”
 	// (for Flash/Flex), “initParams” (for Silverlight) and “GetVariables” (variables
 	// placed in key/value pairs on the asset request).
-	APIFramework string `xml:"apiFramework,attr,omitempty"`
-	URI          string `xml:",cdata"`
+	APIFramework string `xml:"apiFramework,attr,omitempty" json:"api_framework,omitempty"`
+	URI          string `xml:",cdata" json:"uri,omitempty"`
 }
 
 // validate MediaFile
@@ -1100,52 +1067,18 @@ func (media *MediaFile) Validate() error {
 	return nil
 }
 
-func SecureUrl(uri string, secure bool) string {
-
-	uriClear := strings.Replace(uri, "\n", "", -1)
-	uriClear = strings.Replace(uriClear, "\t", "", -1)
-	uriClear = strings.Replace(uriClear, " ", "", -1)
-
-	uriRune := []rune(uriClear)
-
-	if string(uriRune[0:8]) == "https://" {
-		uriRune = uriRune[8:]
-	} else if string(uriRune[0:7]) == "http://" {
-		uriRune = uriRune[7:]
-	} else if string(uriRune[0:2]) == "//" {
-		uriRune = uriRune[2:]
-	} else if string(uriRune[0:3]) == "://" {
-		uriRune = uriRune[3:]
-	}
-
-	uriUrl, err := url.Parse(string(uriRune))
-	if err != nil {
-		return ""
-	}
-
-	if secure {
-		uriUrl.Scheme = "https"
-	} else {
-		uriUrl.Scheme = "http"
-	}
-
-	return uriUrl.String()
-}
-
-// отчистка от муссора
+// ClearBuf removes the insignificant whitespace of a raw document.
+//
+// Deprecated: ClearBuf used to strip every newline and tab, corrupting HTML,
+// JSON and scripts held in CDATA sections. It is now an alias of CompactXML;
+// use VAST.Normalize to trim the parsed URIs and text fields.
 func ClearBuf(buf []byte) []byte {
-
-	clear := strings.Replace(string(buf), "\n", "", -1)
-	clear = strings.Replace(clear, "\t", "", -1)
-
-	return []byte(clear)
+	return CompactXML(buf)
 }
 
-// отчистка от муссора
+// ClearStr is ClearBuf for strings.
+//
+// Deprecated: use CompactXML.
 func ClearStr(buf string) string {
-
-	clear := strings.Replace(buf, "\n", "", -1)
-	clear = strings.Replace(clear, "\t", "", -1)
-
-	return clear
+	return string(CompactXML([]byte(buf)))
 }
@@ -1,9 +1,13 @@
 package vast
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -609,3 +613,129 @@ func TestIcons(t *testing.T) {
 		}
 	}
 }
+
+func TestJSONRoundTrip(t *testing.T) {
+	paths, err := filepath.Glob("testdata/*.xml")
+	assert.NoError(t, err)
+	for _, path := range paths {
+		v, _, _, err := loadFixture(path)
+		if !assert.NoError(t, err, path) {
+			continue
+		}
+		want, err := xml.Marshal(v)
+		assert.NoError(t, err, path)
+
+		b, err := json.Marshal(v)
+		if !assert.NoError(t, err, path) {
+			continue
+		}
+		var v2 VAST
+		if !assert.NoError(t, json.Unmarshal(b, &v2), path) {
+			continue
+		}
+		got, err := xml.Marshal(v2)
+		assert.NoError(t, err, path)
+		assert.Equal(t, string(want), string(got), path)
+	}
+}
+
+func TestJSONEncoding(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_inline_linear.xml")
+	assert.NoError(t, err)
+	linear := v.Ads[0].InLine.Creatives[0].Linear
+	linear.SkipOffset = &Offset{Percent: 0.25}
+	linear.Icons = &Icons{Icon: []Icon{{Program: "AdChoices"}}}
+
+	b, err := json.Marshal(v)
+	assert.NoError(t, err)
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(b, &m))
+
+	assert.Equal(t, "2.0", m["version"])
+	ad := m["ads"].([]interface{})[0].(map[string]interface{})
+	inline := ad["in_line"].(map[string]interface{})
+	assert.Equal(t, "VAST 2.0 Instream Test 1", inline["ad_title"])
+	creative := inline["creatives"].([]interface{})[0].(map[string]interface{})
+	l := creative["linear"].(map[string]interface{})
+	assert.Equal(t, "00:00:30", l["duration"])
+	assert.Equal(t, "25%", l["skip_offset"])
+	tracking := l["tracking_events"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "creativeView", tracking["event"])
+	assert.Equal(t, "http://myTrackingURL/creativeView", tracking["uri"])
+	icons := l["icons"].(map[string]interface{})
+	icon := icons["icon"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "AdChoices", icon["program"])
+}
+
+func TestJSONSchema(t *testing.T) {
+	b, err := ioutil.ReadFile("vast.schema.json")
+	assert.NoError(t, err)
+	var schema struct {
+		Definitions map[string]struct {
+			Properties map[string]json.RawMessage
+		}
+	}
+	if !assert.NoError(t, json.Unmarshal(b, &schema)) {
+		return
+	}
+
+	// every JSON field of the model is described by the schema
+	seen := map[reflect.Type]bool{}
+	var check func(typ reflect.Type)
+	check = func(typ reflect.Type) {
+		for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct || seen[typ] || typ == reflect.TypeOf(CDATAString{}) || typ == reflect.TypeOf(Offset{}) {
+			return
+		}
+		seen[typ] = true
+		def, ok := schema.Definitions[typ.Name()]
+		if !assert.True(t, ok, typ.Name()) {
+			return
+		}
+		fields := 0
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			fields++
+			_, ok := def.Properties[name]
+			assert.True(t, ok, typ.Name()+"."+name)
+			check(f.Type)
+		}
+		assert.Len(t, def.Properties, fields, typ.Name())
+	}
+	check(reflect.TypeOf(VAST{}))
+}
+
+func TestJSONSchemaPatterns(t *testing.T) {
+	b, err := ioutil.ReadFile("vast.schema.json")
+	assert.NoError(t, err)
+	var schema struct {
+		Definitions map[string]struct {
+			Pattern string
+		}
+	}
+	if !assert.NoError(t, json.Unmarshal(b, &schema)) {
+		return
+	}
+	duration := regexp.MustCompile(schema.Definitions["Duration"].Pattern)
+	offset := regexp.MustCompile(schema.Definitions["Offset"].Pattern)
+
+	for _, d := range []Duration{0, Duration(30 * time.Second), Duration(1500 * time.Millisecond), Duration(123 * time.Hour)} {
+		text, err := d.MarshalText()
+		assert.NoError(t, err)
+		assert.True(t, duration.Match(text), string(text))
+		assert.True(t, offset.Match(text), string(text))
+	}
+	text, err := Offset{Percent: 0.5}.MarshalText()
+	assert.NoError(t, err)
+	assert.True(t, offset.Match(text), string(text))
+
+	assert.False(t, duration.MatchString("1:00:00"))
+	assert.False(t, duration.MatchString("00:30"))
+	assert.False(t, offset.MatchString("start"))
+}
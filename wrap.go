@@ -0,0 +1,109 @@
+package vast
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WrapOptions holds our own trackers added to a Wrapper by WrapTag and
+// InlineAsWrapper.
+type WrapOptions struct {
+	// Ad id, WrapTag only; InlineAsWrapper keeps the ids of the inline ads
+	ID string
+	// Ad server reported in AdSystem
+	DisplayManager DisplayManage
+	Impressions    []Impression
+	Viewables      []Viewable
+	Errors         []CDATAString
+	// Trackers and click trackers added to a linear creative
+	Trackings      []Tracking
+	ClickTrackings []VideoClick
+	Extensions     []Extension
+	FallbackOnNoAd *bool
+}
+
+// wrapper returns a Wrapper of tagURL carrying the trackers of opts
+func (opts WrapOptions) wrapper(tagURL string) *Wrapper {
+	return &Wrapper{
+		AdSystem:           opts.DisplayManager.adSystem(),
+		VASTAdTagURI:       CDATAString{tagURL},
+		Impressions:        append([]Impression(nil), opts.Impressions...),
+		ViewableImpression: append([]Viewable(nil), opts.Viewables...),
+		Errors:             append([]CDATAString(nil), opts.Errors...),
+		Extensions:         append([]Extension(nil), opts.Extensions...),
+		FallbackOnNoAd:     opts.FallbackOnNoAd,
+	}
+}
+
+// linear returns a wrapped linear creative carrying the trackers of opts, or
+// nil if there are none
+func (opts WrapOptions) linear() *LinearWrapper {
+	if len(opts.Trackings) == 0 && len(opts.ClickTrackings) == 0 {
+		return nil
+	}
+	l := &LinearWrapper{TrackingEvents: append([]Tracking(nil), opts.Trackings...)}
+	if len(opts.ClickTrackings) > 0 {
+		l.VideoClicks = &VideoClicks{ClickTrackings: append([]VideoClick(nil), opts.ClickTrackings...)}
+	}
+	return l
+}
+
+// WrapTag returns a document with a single Wrapper ad pointing at an upstream
+// tag, carrying our own impressions, error pixels and trackers.
+func WrapTag(tagURL string, opts WrapOptions) (*VAST, error) {
+	if err := validateTagURI(tagURL); err != nil {
+		return nil, err
+	}
+
+	v := &VAST{Version: "3.0", Ads: []Ad{{ID: opts.ID, Wrapper: opts.wrapper(tagURL)}}}
+	if l := opts.linear(); l != nil {
+		v.Ads[0].Wrapper.Creatives = []CreativeWrapper{{Linear: l}}
+	}
+
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// InlineAsWrapper turns a resolved document back into a thin wrapper: each
+// InLine ad becomes a Wrapper of tagURL keeping the ids and sequences of the ad
+// and its creatives, with only the trackers of opts. The document is not
+// modified.
+func (v *VAST) InlineAsWrapper(tagURL string, opts WrapOptions) (*VAST, error) {
+	if err := validateTagURI(tagURL); err != nil {
+		return nil, err
+	}
+	if len(v.Ads) == 0 {
+		return nil, errors.New("empty ads")
+	}
+
+	w := &VAST{Version: v.Version, Ads: make([]Ad, 0, len(v.Ads))}
+	for i, ad := range v.Ads {
+		if ad.InLine == nil {
+			return nil, fmt.Errorf("bad ad[%d] not inline", i)
+		}
+
+		wrap := opts.wrapper(tagURL)
+		for _, c := range ad.InLine.Creatives {
+			cw := CreativeWrapper{ID: c.ID, Sequence: c.Sequence, AdID: c.AdID}
+			if c.Linear != nil {
+				cw.Linear = opts.linear()
+				if cw.Linear == nil {
+					cw.Linear = &LinearWrapper{}
+				}
+			} else if c.NonLinearAds != nil {
+				cw.NonLinearAds = &NonLinearAdsWrapper{TrackingEvents: append([]Tracking(nil), opts.Trackings...)}
+			} else {
+				continue
+			}
+			wrap.Creatives = append(wrap.Creatives, cw)
+		}
+		w.Ads = append(w.Ads, Ad{ID: ad.ID, Sequence: ad.Sequence, Wrapper: wrap})
+	}
+
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
@@ -0,0 +1,78 @@
+package vast
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var wrapOptions = WrapOptions{
+	ID:             "our-1",
+	DisplayManager: DisplayManage{Name: "our-server", Ver: "2.0"},
+	Impressions:    []Impression{{URI: "https://us.com/imp"}, {URI: ""}},
+	Errors:         []CDATAString{{"https://us.com/err?code=[ERRORCODE]"}},
+	Trackings:      []Tracking{{Event: TRACK_COMPLETE, URI: "https://us.com/complete"}},
+	ClickTrackings: []VideoClick{{URI: "https://us.com/click"}},
+}
+
+func TestWrapTag(t *testing.T) {
+	v, err := WrapTag("https://upstream.com/vast", wrapOptions)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if assert.Len(t, v.Ads, 1) && assert.NotNil(t, v.Ads[0].Wrapper) {
+		w := v.Ads[0].Wrapper
+		assert.Equal(t, "our-1", v.Ads[0].ID)
+		assert.Equal(t, &AdSystem{Name: "our-server", Version: "2.0"}, w.AdSystem)
+		assert.Equal(t, "https://upstream.com/vast", w.VASTAdTagURI.CDATA)
+		assert.Equal(t, []Impression{{URI: "https://us.com/imp"}}, w.Impressions)
+		assert.Equal(t, wrapOptions.Errors, w.Errors)
+		if assert.Len(t, w.Creatives, 1) {
+			assert.Equal(t, wrapOptions.Trackings, w.Creatives[0].Linear.TrackingEvents)
+			assert.Equal(t, wrapOptions.ClickTrackings, w.Creatives[0].Linear.VideoClicks.ClickTrackings)
+		}
+	}
+
+	_, err = xml.Marshal(v)
+	assert.NoError(t, err)
+
+	_, err = WrapTag("", wrapOptions)
+	assert.EqualError(t, err, "empty tag uri")
+}
+
+func TestInlineAsWrapper(t *testing.T) {
+	v, _, _, err := loadFixture("testdata/vast_inline_linear.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	w, err := v.InlineAsWrapper("https://us.com/cache/601364", wrapOptions)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if assert.Len(t, w.Ads, 1) && assert.NotNil(t, w.Ads[0].Wrapper) {
+		assert.Equal(t, "601364", w.Ads[0].ID)
+		wrap := w.Ads[0].Wrapper
+		assert.Equal(t, "https://us.com/cache/601364", wrap.VASTAdTagURI.CDATA)
+		assert.Equal(t, "our-server", wrap.AdSystem.Name)
+		// only our trackers are kept, empty ones are dropped
+		assert.Equal(t, []Impression{{URI: "https://us.com/imp"}}, wrap.Impressions)
+		if assert.Len(t, wrap.Creatives, 1) {
+			assert.Equal(t, "601364", wrap.Creatives[0].AdID)
+			assert.Equal(t, wrapOptions.Trackings, wrap.Creatives[0].Linear.TrackingEvents)
+		}
+	}
+
+	// the original document is untouched
+	assert.NotNil(t, v.Ads[0].InLine)
+	assert.Equal(t, "http://myTrackingURL/impression", v.Ads[0].InLine.Impressions[0].URI)
+
+	wv, _, _, err := loadFixture("testdata/vast_wrapper_linear_1.xml")
+	if assert.NoError(t, err) {
+		_, err = wv.InlineAsWrapper("https://us.com/cache", wrapOptions)
+		assert.EqualError(t, err, "bad ad[0] not inline")
+	}
+}